@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"strings"
 
@@ -51,9 +52,58 @@ var snowflakes = NewSet[string]()
 
 func addSnowflakeFile(file string) {
 	for _, line := range strings.Split(file, "\n") {
-		if strings.HasPrefix(line, "#") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 		snowflakes.Add(line)
 	}
 }
+
+//go:embed data/doc-sections.txt
+var embeddedDocSections string
+
+func init() {
+	if err := addDocSectionsFile(embeddedDocSections); err != nil {
+		log.Fatalln("invalid embedded doc sections:", err)
+	}
+}
+
+// docSections maps the paths of schemas to the sections of the documentation
+// tables that they're pinned to.
+var docSections = map[string][]string{}
+
+func addDocSectionsFile(file string) error {
+	pins, err := parseDocSections(file)
+	if err != nil {
+		return err
+	}
+	for path, sections := range pins {
+		docSections[path] = sections
+	}
+	return nil
+}
+
+// parseDocSections parses the pins of the given doc sections file. Unlike the
+// other data files, malformed lines are errors, since skipping a pin would
+// silently fall back to guessing.
+func parseDocSections(file string) (map[string][]string, error) {
+	pins := map[string][]string{}
+	for i, line := range strings.Split(file, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		path, rest, _ := strings.Cut(line, " ")
+		sections := strings.Split(rest, ">")
+		for j, section := range sections {
+			sections[j] = strings.TrimSpace(section)
+			if sections[j] == "" {
+				return nil, fmt.Errorf("line %d: invalid doc section %q", i+1, line)
+			}
+		}
+		pins[path] = sections
+	}
+	return pins, nil
+}
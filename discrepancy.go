@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	stderrors "errors"
+
+	"github.com/pb33f/libopenapi"
+	openapibase "github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pkg/errors"
+	"libdb.so/arikawa-generator/internal/docread"
+)
+
+// discrepancyKind is the kind of disagreement between a schema and its
+// documentation table.
+type discrepancyKind string
+
+const (
+	discrepancyOptional      discrepancyKind = "optional"
+	discrepancyNullable      discrepancyKind = "nullable"
+	discrepancyType          discrepancyKind = "type"
+	discrepancyMissingInDocs discrepancyKind = "missing_in_docs"
+	discrepancyMissingInSpec discrepancyKind = "missing_in_spec"
+)
+
+// discrepancy is a field whose shape in the spec disagrees with its
+// documentation.
+type discrepancy struct {
+	Field string          `json:"field"`
+	Kind  discrepancyKind `json:"kind"`
+	Spec  string          `json:"spec,omitempty"`
+	Docs  string          `json:"docs,omitempty"`
+}
+
+// schemaDiscrepancies are the discrepancies of a schema and the documentation
+// table that it's joined with.
+type schemaDiscrepancies struct {
+	Schema        string        `json:"schema"`
+	Sections      []string      `json:"sections"`
+	Source        string        `json:"source"`
+	Score         float64       `json:"score"`
+	Pinned        bool          `json:"pinned,omitempty"`
+	Discrepancies []discrepancy `json:"discrepancies"`
+}
+
+// ReportDiscrepancies joins each object schema of the given document with its
+// most likely documentation table and reports every field that the two
+// disagree on. The report is formatted as either markdown or json. Schemas
+// pinned in docSections use their pinned table, and other schemas without a
+// table above the threshold are skipped.
+func ReportDiscrepancies(doc libopenapi.Document, format string) ([]byte, error) {
+	v3doc, errs := doc.BuildV3Model()
+	if errs != nil {
+		err := stderrors.Join(errs...)
+		return nil, errors.Wrap(err, "failed to build OpenAPI v3 model")
+	}
+
+	state := newState(context.TODO())
+	trimSchemaNames(state, v3doc.Model.Components.Schemas)
+
+	var reports []schemaDiscrepancies
+	schemasIter := orderedMap(v3doc.Model.Components.Schemas)
+	schemasIter(func(name string, proxy *openapibase.SchemaProxy) bool {
+		path := schemaPath{{Name: name, SchemaProxy: proxy}}
+		schema := proxy.Schema()
+		if ptype, _ := extractPrimaryType(schema.Type); ptype.Type != "object" {
+			return true
+		}
+
+		var table docLikelihood
+		pinned, isPinned := pinnedDocs[path.String()]
+		if isPinned {
+			table = docLikelihood{
+				ObjectTable: pinned,
+				Likelihood:  docread.CalculateObjectLikelihood(objectToDocTable(path, schema), pinned.FieldTable()),
+			}
+		} else {
+			candidates := calculateTopLikelihood(path, schema)
+			if len(candidates) == 0 || candidates[0].Likelihood.Score < docsThreshold {
+				return true
+			}
+			table = candidates[0]
+		}
+
+		found := compareDocTable(schema, objectToDocTable(path, schema), table.FieldInfos())
+		if len(found) > 0 {
+			reports = append(reports, schemaDiscrepancies{
+				Schema:        pascalToGo(name),
+				Sections:      table.Sections,
+				Source:        docSourcePath(documentationDir, table.Source.Path) + fmt.Sprintf("#L%d", table.Source.Line),
+				Score:         table.Likelihood.Score,
+				Pinned:        isPinned,
+				Discrepancies: found,
+			})
+		}
+		return true
+	})
+
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(reports, "", "\t")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	case "markdown":
+		return formatDiscrepancies(reports), nil
+	default:
+		return nil, fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// compareDocTable returns the discrepancies between the fields of the given
+// object schema and the fields of its documentation table. Fields are reported
+// in the order of the schema, followed by the fields that are only documented.
+func compareDocTable(object *openapibase.Schema, spec docread.FieldTable, docs []docread.FieldInfo) []discrepancy {
+	specFields := docread.ToFieldMap(spec.Fields)
+	docFields := docread.ToFieldMap(docs)
+
+	var found []discrepancy
+	for _, property := range objectProperties(object) {
+		s := specFields[property.Name]
+		d, ok := docFields[property.Name]
+		if !ok {
+			found = append(found, discrepancy{
+				Field: s.Name,
+				Kind:  discrepancyMissingInDocs,
+				Spec:  s.TypeExpr.String(),
+			})
+			continue
+		}
+
+		if s.Optional != d.Optional {
+			found = append(found, discrepancy{
+				Field: s.Name,
+				Kind:  discrepancyOptional,
+				Spec:  optionalWord(s.Optional),
+				Docs:  optionalWord(d.Optional),
+			})
+		}
+		if s.Nullable != d.Nullable {
+			found = append(found, discrepancy{
+				Field: s.Name,
+				Kind:  discrepancyNullable,
+				Spec:  nullableWord(s.Nullable),
+				Docs:  nullableWord(d.Nullable),
+			})
+		}
+		if typesDisagree(s.TypeExpr, d.TypeExpr) {
+			found = append(found, discrepancy{
+				Field: s.Name,
+				Kind:  discrepancyType,
+				Spec:  s.TypeExpr.String(),
+				Docs:  d.TypeExpr.String(),
+			})
+		}
+	}
+
+	for _, d := range docs {
+		if _, ok := specFields[d.Name]; !ok {
+			found = append(found, discrepancy{
+				Field: d.Name,
+				Kind:  discrepancyMissingInSpec,
+				Docs:  d.TypeExpr.String(),
+			})
+		}
+	}
+
+	return found
+}
+
+// typesDisagree returns whether the given types are incompatible regardless of
+// their nullability, which is reported on its own. Unknown types are never
+// reported, since they can't be compared.
+func typesDisagree(a, b docread.TypeExpr) bool {
+	if a.Kind == docread.UnknownType || b.Kind == docread.UnknownType {
+		return false
+	}
+	a.Nullable = false
+	b.Nullable = false
+	return docread.TypeSimilarity(a, b) == 0
+}
+
+func optionalWord(optional bool) string {
+	if optional {
+		return "optional"
+	}
+	return "required"
+}
+
+func nullableWord(nullable bool) string {
+	if nullable {
+		return "nullable"
+	}
+	return "non-nullable"
+}
+
+// formatDiscrepancies formats the given reports as a Markdown document with a
+// section and a table for each schema.
+func formatDiscrepancies(reports []schemaDiscrepancies) []byte {
+	var b strings.Builder
+	b.WriteString("# Spec and documentation discrepancies\n")
+	if len(reports) == 0 {
+		b.WriteString("\nNo discrepancies were found.\n")
+	}
+
+	for _, report := range reports {
+		fmt.Fprintf(&b, "\n## %s\n\n", report.Schema)
+		if report.Pinned {
+			fmt.Fprintf(&b, "Pinned to %s (`%s`) with a score of %.02f.\n\n",
+				strings.Join(report.Sections, " › "), report.Source, report.Score)
+		} else {
+			fmt.Fprintf(&b, "Joined with %s (`%s`) with a score of %.02f.\n\n",
+				strings.Join(report.Sections, " › "), report.Source, report.Score)
+		}
+
+		b.WriteString("| Field | Discrepancy | Spec | Docs |\n")
+		b.WriteString("| ----- | ----------- | ---- | ---- |\n")
+		for _, d := range report.Discrepancies {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n",
+				d.Field, d.Kind, markdownCell(d.Spec), markdownCell(d.Docs))
+		}
+	}
+
+	return []byte(b.String())
+}
+
+// markdownCell escapes the given text for a Markdown table cell.
+func markdownCell(s string) string {
+	if s == "" {
+		return "–"
+	}
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/pb33f/libopenapi"
+	"libdb.so/arikawa-generator/internal/docread"
+)
+
+const discrepancySpec = `{
+	"openapi": "3.1.0",
+	"info": {"title": "test", "version": "1"},
+	"paths": {},
+	"components": {
+		"schemas": {
+			"Channel": {
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "format": "snowflake"},
+					"topic": {"type": ["string", "null"]},
+					"position": {"type": "integer"},
+					"extra": {"type": "boolean"}
+				},
+				"required": ["id", "topic", "position", "extra"]
+			}
+		}
+	}
+}`
+
+func TestCompareDocTable(t *testing.T) {
+	doc, err := libopenapi.NewDocument([]byte(discrepancySpec))
+	assert.NoError(t, err)
+
+	v3doc, errs := doc.BuildV3Model()
+	assert.Equal(t, 0, len(errs))
+
+	proxy := v3doc.Model.Components.Schemas["Channel"]
+	path := schemaPath{{Name: "Channel", SchemaProxy: proxy}}
+	schema := proxy.Schema()
+
+	rows := []docread.ObjectTableRow{
+		{Field: "id", Type: "snowflake"},
+		{Field: "topic?", Type: "?string"},
+		{Field: "position", Type: "string"},
+		{Field: "nsfw?", Type: "boolean"},
+	}
+	docs := make([]docread.FieldInfo, len(rows))
+	for i, row := range rows {
+		docs[i] = row.FieldInfo()
+	}
+
+	found := compareDocTable(schema, objectToDocTable(path, schema), docs)
+	assert.Equal(t, []discrepancy{
+		{Field: "topic", Kind: discrepancyOptional, Spec: "required", Docs: "optional"},
+		{Field: "position", Kind: discrepancyType, Spec: "integer", Docs: "string"},
+		{Field: "extra", Kind: discrepancyMissingInDocs, Spec: "boolean"},
+		{Field: "nsfw", Kind: discrepancyMissingInSpec, Docs: "boolean"},
+	}, found)
+}
+
+func TestFormatDiscrepancies(t *testing.T) {
+	assert.Equal(t, "# Spec and documentation discrepancies\n\nNo discrepancies were found.\n",
+		string(formatDiscrepancies(nil)))
+
+	reports := []schemaDiscrepancies{{
+		Schema:   "Channel",
+		Sections: []string{"Channel", "Channel Object", "Channel Structure"},
+		Source:   "docs/resources/Channel.md#L10",
+		Score:    9.5,
+		Discrepancies: []discrepancy{
+			{Field: "topic", Kind: discrepancyNullable, Spec: "non-nullable", Docs: "nullable"},
+			{Field: "kind", Kind: discrepancyType, Spec: "integer", Docs: "string | integer"},
+			{Field: "nsfw", Kind: discrepancyMissingInSpec, Docs: "boolean"},
+		},
+	}}
+
+	assert.Equal(t, "# Spec and documentation discrepancies\n"+
+		"\n"+
+		"## Channel\n"+
+		"\n"+
+		"Joined with Channel › Channel Object › Channel Structure (`docs/resources/Channel.md#L10`) with a score of 9.50.\n"+
+		"\n"+
+		"| Field | Discrepancy | Spec | Docs |\n"+
+		"| ----- | ----------- | ---- | ---- |\n"+
+		"| `topic` | nullable | non-nullable | nullable |\n"+
+		"| `kind` | type | integer | string \\| integer |\n"+
+		"| `nsfw` | missing_in_spec | – | boolean |\n",
+		string(formatDiscrepancies(reports)))
+}
+
+func TestReportPinnedDiscrepancies(t *testing.T) {
+	pinnedDocs = map[string]docread.ObjectTable{
+		"Channel": {
+			Sections: []string{"Channel", "Channel Object", "Channel Structure"},
+			Table: []docread.ObjectTableRow{
+				{Field: "id", Type: "snowflake"},
+				{Field: "topic", Type: "?string"},
+				{Field: "position", Type: "integer"},
+				{Field: "nsfw?", Type: "boolean"},
+			},
+			Source: docread.Source{Path: "Channel.md", Line: 10},
+		},
+	}
+	t.Cleanup(func() { pinnedDocs = nil })
+
+	doc, err := libopenapi.NewDocument([]byte(discrepancySpec))
+	assert.NoError(t, err)
+
+	b, err := ReportDiscrepancies(doc, "json")
+	assert.NoError(t, err)
+
+	var reports []schemaDiscrepancies
+	assert.NoError(t, json.Unmarshal(b, &reports))
+	assert.Equal(t, 1, len(reports))
+	assert.True(t, reports[0].Pinned)
+	assert.True(t, reports[0].Score > 0, "pinned tables must be scored")
+	assert.Equal(t, []discrepancy{
+		{Field: "extra", Kind: discrepancyMissingInDocs, Spec: "boolean"},
+		{Field: "nsfw", Kind: discrepancyMissingInSpec, Docs: "boolean"},
+	}, reports[0].Discrepancies)
+
+	md := formatDiscrepancies(reports)
+	assert.Contains(t, string(md), "Pinned to Channel › Channel Object › Channel Structure (`")
+	assert.Contains(t, string(md), "`) with a score of ")
+}
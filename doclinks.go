@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	openapibase "github.com/pb33f/libopenapi/datamodel/high/base"
+	"libdb.so/arikawa-generator/internal/cmt"
+	"libdb.so/arikawa-generator/internal/docread"
+)
+
+// docsURL is the URL of the Discord developer documentation.
+const docsURL = "https://discord.com/developers/docs"
+
+// docType is a generated type that is documented by a section of the
+// documentation.
+type docType struct {
+	Anchor string
+	Path   string // path of the documentation file
+	Name   string // Go name
+}
+
+// registerDocTypes registers the types of the given schemas that are
+// documented by a section of the documentation, so that links to these
+// sections can be resolved into Go doc links.
+func registerDocTypes(state *generateState, schemas map[string]*openapibase.SchemaProxy) {
+	g := &generator{state: state}
+
+	register := func(name string, sections []string, source docread.Source) {
+		anchors := []string{docread.SectionAnchor(sections)}
+		// Objects are linked to by their section instead of the section of
+		// their structure table, e.g. user-object for User Structure.
+		if len(sections) > 1 && strings.HasSuffix(sections[len(sections)-1], " Structure") {
+			anchors = append(anchors, docread.SectionAnchor(sections[len(sections)-2:len(sections)-1]))
+		}
+		for _, anchor := range anchors {
+			state.docTypes = append(state.docTypes, docType{
+				Anchor: anchor,
+				Path:   source.Path,
+				Name:   pascalToGo(name),
+			})
+		}
+	}
+
+	schemasIter := orderedMap(schemas)
+	schemasIter(func(name string, proxy *openapibase.SchemaProxy) bool {
+		path := schemaPath{{Name: name, SchemaProxy: proxy}}
+		schema := proxy.Schema()
+
+		if ptype, _ := extractPrimaryType(schema.Type); ptype.Type == "object" {
+			if table, ok := g.docTable(path, schema); ok {
+				register(name, table.Sections, table.Source)
+			}
+			return true
+		}
+
+		if table, ok := g.docEnum(path); ok {
+			register(name, table.Sections, table.Source)
+		}
+		if table, ok := g.docFlags(path); ok {
+			register(name, table.Sections, table.Source)
+		}
+		return true
+	})
+}
+
+// docComment formats the given documentation of self as a comment using
+// cmt.Prettify. Links within the documentation are resolved into Go doc
+// links.
+func (g *generator) docComment(self, doc string, opts cmt.Opts) string {
+	text, defs := g.resolveDocLinks(doc)
+
+	// Hide the brackets of links from cmt.Prettify, which would otherwise
+	// drop them from links such as [hash] that are also the names of
+	// standard library packages.
+	text = linkBracketsHider.Replace(text)
+
+	var b strings.Builder
+	b.WriteString(linkBracketsRevealer.Replace(cmt.Prettify(self, text, opts)))
+	if len(defs) > 0 {
+		// Link definitions must be after the text, since cmt.Prettify would
+		// wrap them.
+		indent := strings.Repeat("\t", opts.Indent)
+		fmt.Fprintf(&b, "%s//\n", indent)
+		for _, def := range defs {
+			fmt.Fprintf(&b, "%s// %s\n", indent, def)
+		}
+	}
+	return b.String()
+}
+
+var (
+	linkBracketsHider    = strings.NewReplacer("[", "\uE000", "]", "\uE001")
+	linkBracketsRevealer = strings.NewReplacer("\uE000", "[", "\uE001", "]")
+)
+
+var markdownLinkRe = regexp.MustCompile(`\[([^\[\]]+)\]\(([^()\s]+)\)`)
+
+// resolveDocLinks replaces the Markdown links within the given documentation.
+// Links to documented types become Go doc links to the types, e.g. [User].
+// Other links become Go doc links with the given link definitions, which
+// point to the absolute URLs of the links.
+func (g *generator) resolveDocLinks(doc string) (string, []string) {
+	var defs []string
+	urls := map[string]string{}
+
+	text := markdownLinkRe.ReplaceAllStringFunc(doc, func(link string) string {
+		m := markdownLinkRe.FindStringSubmatch(link)
+		title, target := m[1], m[2]
+
+		var url string
+		switch {
+		case strings.HasPrefix(target, "#DOCS_"):
+			page, anchor, _ := strings.Cut(strings.TrimPrefix(target, "#"), "/")
+			if name, ok := g.docTypeName(page, anchor); ok {
+				return "[" + name + "]"
+			}
+			url = docPageURL(page, anchor)
+		case strings.HasPrefix(target, "https://"), strings.HasPrefix(target, "http://"):
+			url = target
+		default:
+			// Relative links can't be resolved.
+			return title
+		}
+
+		if existing, ok := urls[title]; ok && existing != url {
+			// Link definitions must have unique titles.
+			return title + " (" + url + ")"
+		}
+		if _, ok := urls[title]; !ok {
+			urls[title] = url
+			defs = append(defs, "["+title+"]: "+url)
+		}
+		return "[" + title + "]"
+	})
+
+	return text, defs
+}
+
+// docTypeName returns the Go name of the type documented by the section with
+// the given anchor within the given page, e.g. DOCS_RESOURCES_USER.
+func (g *generator) docTypeName(page, anchor string) (string, bool) {
+	for _, t := range g.state.docTypes {
+		if t.Anchor == anchor && docPageMatches(page, t.Path) {
+			return t.Name, true
+		}
+	}
+	return "", false
+}
+
+// docPageURL returns the absolute URL of the given page and anchor, e.g.
+// DOCS_RESOURCES_USER and user-object.
+func docPageURL(page, anchor string) string {
+	parts := strings.Split(strings.TrimPrefix(page, "DOCS_"), "_")
+	url := docsURL + "/" + strings.ToLower(parts[0])
+	if len(parts) > 1 {
+		url += "/" + strings.ToLower(strings.Join(parts[1:], "-"))
+	}
+	if anchor != "" {
+		url += "#" + anchor
+	}
+	return url
+}
@@ -2,18 +2,29 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
 	"sync"
 
+	stderrors "errors"
+	stdpath "path"
+
+	"github.com/hashicorp/go-hclog"
 	openapibase "github.com/pb33f/libopenapi/datamodel/high/base"
 	"golang.org/x/exp/slices"
 	"libdb.so/arikawa-generator/internal/docread"
 )
 
-var knownDocTables []docread.ObjectTable
+var (
+	knownDocTables []docread.ObjectTable
+	knownDocIndex  docread.FieldIndex
+	pinnedDocs     map[string]docread.ObjectTable
+	knownDocEnums  []docread.EnumTable
+	knownDocFlags  []docread.FlagTable
+)
 
 func scrapeDocs() error {
 	t, err := docread.ScrapeDir(documentationDir)
@@ -21,9 +32,90 @@ func scrapeDocs() error {
 		return err
 	}
 	knownDocTables = append(knownDocTables, t...)
+	knownDocIndex = docread.NewFieldIndex(knownDocTables)
+
+	pinnedDocs, err = pinDocSections(docSections, knownDocTables)
+	if err != nil {
+		return err
+	}
+
+	e, err := docread.ScrapeEnumDir(documentationDir)
+	if err != nil {
+		return err
+	}
+	knownDocEnums = append(knownDocEnums, e...)
+
+	f, err := docread.ScrapeFlagDir(documentationDir)
+	if err != nil {
+		return err
+	}
+	knownDocFlags = append(knownDocFlags, f...)
+	logSkippedFlags(f)
+
+	if errorCodesDocsFile != "" {
+		if err := scrapeErrorCodeDocs(); err != nil {
+			return err
+		}
+	}
+
+	if permissionsDocsFile != "" {
+		if err := scrapePermissionDocs(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// logSkippedFlags logs the rows of the given flag tables that couldn't be read.
+func logSkippedFlags(tables []docread.FlagTable) {
+	log := hclog.Default()
+	for _, table := range tables {
+		for _, err := range table.Skipped {
+			log.Warn("skipping unreadable flag row",
+				"sections", table.Sections,
+				"source", table.Source.String(),
+				"error", err)
+		}
+	}
+}
+
+// pinDocSections returns the tables that the given schema paths are pinned to
+// by their sections. It fails if a pinned section has no table or more than
+// one, since the pin would otherwise silently fall back to guessing or pick an
+// arbitrary table.
+func pinDocSections(pins map[string][]string, tables []docread.ObjectTable) (map[string]docread.ObjectTable, error) {
+	pinned := make(map[string]docread.ObjectTable, len(pins))
+
+	var errs []error
+	for path, sections := range pins {
+		var matches []docread.ObjectTable
+		for _, table := range tables {
+			if slices.Equal(table.Sections, sections) {
+				matches = append(matches, table)
+			}
+		}
+
+		switch len(matches) {
+		case 0:
+			errs = append(errs, fmt.Errorf("%s is pinned to unknown doc section %q",
+				path, strings.Join(sections, " > ")))
+		case 1:
+			pinned[path] = matches[0]
+		default:
+			sources := make([]string, len(matches))
+			for i, match := range matches {
+				sources[i] = match.Source.String()
+			}
+			errs = append(errs, fmt.Errorf("%s is pinned to ambiguous doc section %q with tables at %s",
+				path, strings.Join(sections, " > "), strings.Join(sources, ", ")))
+		}
+	}
+
+	// Sort the errors so that they're reported in the same order.
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return pinned, stderrors.Join(errs...)
+}
+
 const maxLikelihoodCandidates = 5
 const minLikelihood = 5
 
@@ -34,18 +126,125 @@ type docLikelihood struct {
 	Likelihood docread.ObjectLikelihood
 }
 
-func logLikelihoods(path schemaPath, candidates []docLikelihood) {
-	var b strings.Builder
-	fmt.Fprintln(&b, path, "might correlate to these tables:")
-	for _, doc := range candidates {
-		fmt.Fprintf(&b, "  - %.02f: %q\n", doc.Likelihood.Score, doc.Sections)
+// docMiss is an object schema that has no documentation table with a
+// likelihood above the threshold.
+type docMiss struct {
+	Path       string
+	Candidates []docLikelihood
+}
+
+func (e *generateState) addDocMiss(path schemaPath, candidates []docLikelihood) {
+	e.Lock()
+	defer e.Unlock()
+	e.docMisses[path.String()] = docMiss{
+		Path:       path.String(),
+		Candidates: candidates,
+	}
+}
+
+// docTable returns the documentation table of the given object schema. False
+// is returned if documentation is disabled or if no table is likely enough to
+// be the object, in which case the object is added to the report.
+func (g *generator) docTable(path schemaPath, object *openapibase.Schema) (docread.ObjectTable, bool) {
+	if !withDocs {
+		return docread.ObjectTable{}, false
 	}
-	log.Println(b.String())
+
+	if table, ok := pinnedDocs[path.String()]; ok {
+		log := hclog.FromContext(g.state.ctx)
+		log.Debug("using pinned documentation table",
+			"path", path.String(),
+			"sections", table.Sections,
+			"source", table.Source.String())
+
+		return table, true
+	}
+
+	candidates := calculateTopLikelihood(path, object)
+	if len(candidates) == 0 || candidates[0].Likelihood.Score < docsThreshold {
+		g.state.addDocMiss(path, candidates)
+		return docread.ObjectTable{}, false
+	}
+
+	log := hclog.FromContext(g.state.ctx)
+	log.Debug("matched documentation table",
+		"path", path.String(),
+		"sections", candidates[0].Sections,
+		"source", candidates[0].Source.String(),
+		"score", candidates[0].Likelihood.Score)
+
+	return candidates[0].ObjectTable, true
+}
+
+// setDocSource records the source of the documentation table of the given
+// schema if it's a root schema, so that its declaration notes where it's
+// documented.
+func (g *generator) setDocSource(path schemaPath, source docread.Source) {
+	if path.IsRoot() {
+		g.source = source
+	}
+}
+
+// docSourceComment returns the comment noting the given source of a
+// documentation table, e.g. "// Source: docs/resources/Webhook.md#L42", or an
+// empty string if there's no source. The path of the source is relative to the
+// given directory.
+func docSourceComment(dir string, source docread.Source) string {
+	if source.Path == "" {
+		return ""
+	}
+	return fmt.Sprintf("// Source: %s#L%d\n", docSourcePath(dir, source.Path), source.Line)
+}
+
+// docSourcePath returns the path of the given documentation file, which is
+// relative to the given directory. The path is made relative to
+// $DISCORD_API_DOCS if the directory is within it, since absolute paths would
+// differ between machines.
+func docSourcePath(dir, path string) string {
+	root := os.Getenv("DISCORD_API_DOCS")
+	if root == "" {
+		return path
+	}
+	rel, err := filepath.Rel(root, filepath.Join(dir, path))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// logDocReport logs all object schemas that have no documentation table with
+// a likelihood above the threshold along with their best candidates.
+func logDocReport(state *generateState) {
+	if len(state.docMisses) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	missesIter := orderedMap(state.docMisses)
+	missesIter(func(_ string, miss docMiss) bool {
+		if len(miss.Candidates) == 0 {
+			fmt.Fprintf(&b, "%s: no candidates\n", miss.Path)
+			return true
+		}
+		fmt.Fprintf(&b, "%s might correlate to these tables:\n", miss.Path)
+		for _, doc := range miss.Candidates {
+			fmt.Fprintf(&b, "  - %.02f: %q (%s#L%d)\n",
+				doc.Likelihood.Score, doc.Sections, docSourcePath(documentationDir, doc.Source.Path), doc.Source.Line)
+		}
+		return true
+	})
+
+	log := hclog.FromContext(state.ctx)
+	log.Warn("some objects have no documentation table above the threshold",
+		"threshold", docsThreshold,
+		"count", len(state.docMisses),
+		"report", b.String())
 }
 
 // calculateTopLikelihood returns the top 5 most likely objects that the given
 // object is. The first object is the most likely, and the last object is the
-// least likely.
+// least likely. Objects that are equally likely are ordered by their source,
+// so the order is the same across runs.
 func calculateTopLikelihood(path schemaPath, object *openapibase.Schema) []docLikelihood {
 	id := object.GoLow().Hash()
 	if cached, ok := computedLikelihoods.Load(id); ok {
@@ -53,50 +252,42 @@ func calculateTopLikelihood(path schemaPath, object *openapibase.Schema) []docLi
 	}
 
 	fields := objectToDocTable(path, object)
-	candidates := make([]docLikelihood, 0, maxLikelihoodCandidates)
+	var candidates []docLikelihood
 
-	for _, table := range knownDocTables {
+	// Only tables with a field in common are scored. The others can at most
+	// score the weight of their names, which is below minLikelihood.
+	for _, ix := range knownDocIndex.Lookup(fields.Fields) {
+		table := knownDocTables[ix]
 		likelihood := docread.CalculateObjectLikelihood(fields, table.FieldTable())
 		if likelihood.Score < minLikelihood {
 			continue
 		}
 
-		if len(candidates) < maxLikelihoodCandidates {
-			candidates = append(candidates, docLikelihood{
-				ObjectTable: table,
-				Likelihood:  likelihood,
-			})
-			continue
-		}
-
-		minIx := minLikelihoodCandidate(candidates)
-		if likelihood.Score > candidates[minIx].Likelihood.Score {
-			candidates[minIx] = docLikelihood{
-				ObjectTable: table,
-				Likelihood:  likelihood,
-			}
-		}
+		candidates = append(candidates, docLikelihood{
+			ObjectTable: table,
+			Likelihood:  likelihood,
+		})
 	}
 
-	// Sort candidates by likelihood.
-	sort.Slice(candidates, func(i, j int) bool {
-		return candidates[i].Likelihood.Score > candidates[j].Likelihood.Score
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Likelihood.Score != b.Likelihood.Score {
+			return a.Likelihood.Score > b.Likelihood.Score
+		}
+		if a.Source.Path != b.Source.Path {
+			return a.Source.Path < b.Source.Path
+		}
+		return a.Source.Line < b.Source.Line
 	})
 
+	if len(candidates) > maxLikelihoodCandidates {
+		candidates = candidates[:maxLikelihoodCandidates]
+	}
+
 	computedLikelihoods.Store(id, candidates)
 	return candidates
 }
 
-func minLikelihoodCandidate(candidates []docLikelihood) int {
-	min := 0
-	for i, candidate := range candidates {
-		if candidate.Likelihood.Score < candidates[min].Likelihood.Score {
-			min = i
-		}
-	}
-	return min
-}
-
 var capitalLetterRe = regexp.MustCompile(`[A-Z]`)
 
 func objectToDocTable(path schemaPath, object *openapibase.Schema) docread.FieldTable {
@@ -110,6 +301,7 @@ func objectToDocTable(path schemaPath, object *openapibase.Schema) docread.Field
 		fields = append(fields, docread.FieldInfo{
 			Name:     name,
 			Type:     ptype.Type,
+			TypeExpr: schemaTypeExpr(prop),
 			Nullable: ptype.Nullable,
 			Optional: !required,
 		})
@@ -129,9 +321,169 @@ func objectToDocTable(path schemaPath, object *openapibase.Schema) docread.Field
 	}
 }
 
+// schemaTypeExpr returns the type expression of the given schema, so that it
+// can be compared to the types within the documentation.
+func schemaTypeExpr(schema *openapibase.Schema) docread.TypeExpr {
+	ptype, _ := extractPrimaryType(schema.Type)
+	expr := docread.TypeExpr{
+		Nullable: ptype.Nullable,
+		Raw:      ptype.Type,
+	}
+
+	switch ptype.Type {
+	case "string":
+		expr.Kind = docread.PrimitiveType
+		switch schema.Format {
+		case "snowflake":
+			expr.Primitive = docread.PrimitiveSnowflake
+		case "date-time":
+			expr.Primitive = docread.PrimitiveTimestamp
+		default:
+			expr.Primitive = docread.PrimitiveString
+		}
+	case "integer":
+		expr.Kind = docread.PrimitiveType
+		expr.Primitive = docread.PrimitiveInteger
+	case "number":
+		expr.Kind = docread.PrimitiveType
+		expr.Primitive = docread.PrimitiveFloat
+	case "boolean":
+		expr.Kind = docread.PrimitiveType
+		expr.Primitive = docread.PrimitiveBoolean
+	case "object":
+		expr.Kind = docread.ObjectType
+	case "array":
+		if schema.Items == nil || !schema.Items.IsA() || schema.Items.A.Schema() == nil {
+			break
+		}
+		elem := schemaTypeExpr(schema.Items.A.Schema())
+		expr.Kind = docread.ArrayType
+		expr.Elem = &elem
+	case "":
+		// Nullable references are wrapped in an allOf.
+		if len(schema.AllOf) == 1 && schema.AllOf[0].Schema() != nil {
+			elem := schemaTypeExpr(schema.AllOf[0].Schema())
+			elem.Nullable = elem.Nullable || ptype.Nullable
+			return elem
+		}
+	}
+
+	return expr
+}
+
 func propIsNullable(object *openapibase.Schema, propName string) bool {
 	required := slices.Contains(object.Required, propName)
 	property := object.Properties[propName].Schema()
 	ptype, _ := extractPrimaryType(property.Type)
 	return !required || ptype.Nullable
 }
+
+// docLinkRe matches links to other pages of the documentation, e.g.
+// [type](#DOCS_RESOURCES_WEBHOOK/webhook-object-webhook-types).
+var docLinkRe = regexp.MustCompile(`\]\(#(DOCS_[A-Z0-9_]+)/([a-z0-9-]+)\)`)
+
+// docPageMatches returns whether the documentation page with the given link
+// name, e.g. DOCS_RESOURCES_WEBHOOK, is the file at the given path.
+func docPageMatches(page, path string) bool {
+	file := stdpath.Base(path)
+	file = strings.TrimSuffix(file, stdpath.Ext(file))
+	file = strings.ToUpper(strings.ReplaceAll(file, "-", "_"))
+	return page == file || strings.HasSuffix(page, "_"+file)
+}
+
+// docEnum returns the documentation table of the values of the enum at the
+// given path.
+func (g *generator) docEnum(path schemaPath) (docread.EnumTable, bool) {
+	i := g.findDocSection(path, len(knownDocEnums), func(i int) ([]string, docread.Source) {
+		return knownDocEnums[i].Sections, knownDocEnums[i].Source
+	})
+	if i == -1 {
+		return docread.EnumTable{}, false
+	}
+	return knownDocEnums[i], true
+}
+
+// docFlags returns the documentation table of the flags of the bitfield at
+// the given path.
+func (g *generator) docFlags(path schemaPath) (docread.FlagTable, bool) {
+	i := g.findDocSection(path, len(knownDocFlags), func(i int) ([]string, docread.Source) {
+		return knownDocFlags[i].Sections, knownDocFlags[i].Source
+	})
+	if i == -1 {
+		return docread.FlagTable{}, false
+	}
+	return knownDocFlags[i], true
+}
+
+// findDocSection returns the index of the documentation section that
+// describes the type of the schema at the given path out of n sections, or -1
+// if there is none. The section is found using the links in the documentation
+// of the field. If there are none, then the section is found using the name
+// of the type.
+func (g *generator) findDocSection(path schemaPath, n int, section func(i int) ([]string, docread.Source)) int {
+	if !withDocs {
+		return -1
+	}
+
+	for _, link := range g.docFieldLinks(path) {
+		for i := 0; i < n; i++ {
+			sections, source := section(i)
+			if docread.SectionAnchor(sections) == link[2] && docPageMatches(link[1], source.Path) {
+				return i
+			}
+		}
+	}
+
+	name := normalizeDocName(typeNameFromPath(path))
+	for i := 0; i < n; i++ {
+		sections, _ := section(i)
+		if normalizeDocName(sections[len(sections)-1]) == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// docFieldLinks returns the documentation links in the type and the
+// description of the field at the given path, if its object has a
+// documentation table. Each link is a docLinkRe submatch.
+func (g *generator) docFieldLinks(path schemaPath) [][]string {
+	fieldName := path.CurrentName()
+	parent := path.Parent().PopPrivateLeaves()
+	if fieldName == "" || len(parent) == 0 || parent.CurrentProxy() == nil {
+		return nil
+	}
+
+	parentSchema := parent.Current()
+	if _, ok := parentSchema.Properties[fieldName]; !ok {
+		return nil
+	}
+
+	object, ok := g.docTable(parent, parentSchema)
+	if !ok {
+		return nil
+	}
+
+	for _, row := range object.Table {
+		if row.FieldInfo().Name == fieldName {
+			return docLinkRe.FindAllStringSubmatch(row.Type+" "+row.Description, -1)
+		}
+	}
+
+	return nil
+}
+
+// normalizeDocName normalizes the given name of a type or a section so that
+// they can be compared, e.g. ChannelTypes and "Channel Types" are both
+// normalized to channeltype.
+func normalizeDocName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return -1
+	}, name)
+	return strings.TrimSuffix(name, "s")
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"libdb.so/arikawa-generator/internal/docread"
+)
+
+func TestParseDocSections(t *testing.T) {
+	pins, err := parseDocSections(`
+# comment
+WebhookResponse Webhook Resource > Webhook Object > Webhook Structure
+Channel   Channels Resource>Channel Object >Channel Structure
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"WebhookResponse": {"Webhook Resource", "Webhook Object", "Webhook Structure"},
+		"Channel":         {"Channels Resource", "Channel Object", "Channel Structure"},
+	}, pins)
+
+	for _, file := range []string{
+		"WebhookResponse",
+		"WebhookResponse Webhook Resource > > Webhook Structure",
+		"WebhookResponse Webhook Resource > Webhook Object >",
+	} {
+		_, err := parseDocSections(file)
+		assert.Error(t, err, file)
+	}
+}
+
+func TestPinDocSections(t *testing.T) {
+	tables := []docread.ObjectTable{
+		{
+			Sections: []string{"Webhook Resource", "Webhook Object", "Webhook Structure"},
+			Source:   docread.Source{Path: "Webhook.md", Line: 13},
+		},
+		{
+			Sections: []string{"Channels Resource", "Channel Object", "Channel Structure"},
+			Source:   docread.Source{Path: "Channel.md", Line: 9},
+		},
+		{
+			Sections: []string{"Channels Resource", "Channel Object", "Channel Structure"},
+			Source:   docread.Source{Path: "Thread.md", Line: 4},
+		},
+	}
+
+	pinned, err := pinDocSections(map[string][]string{
+		"WebhookResponse": {"Webhook Resource", "Webhook Object", "Webhook Structure"},
+	}, tables)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]docread.ObjectTable{"WebhookResponse": tables[0]}, pinned)
+
+	_, err = pinDocSections(map[string][]string{
+		"WebhookResponse": {"Webhook Resource", "Webhook Object"},
+	}, tables)
+	assert.EqualError(t, err,
+		`WebhookResponse is pinned to unknown doc section "Webhook Resource > Webhook Object"`)
+
+	_, err = pinDocSections(map[string][]string{
+		"Channel": {"Channels Resource", "Channel Object", "Channel Structure"},
+	}, tables)
+	assert.EqualError(t, err,
+		`Channel is pinned to ambiguous doc section "Channels Resource > Channel Object > Channel Structure" `+
+			`with tables at Channel.md#L9, Thread.md#L4`)
+}
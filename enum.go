@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	openapibase "github.com/pb33f/libopenapi/datamodel/high/base"
+	"libdb.so/arikawa-generator/internal/docread"
+)
+
+// generateNamedType generates a type with the given underlying type whose
+// declaration is followed by the code written by body, such as constants and
+// methods. Types that aren't at the root are generated globally, so their name
+// must not collide with a schema in the document.
+func (g *generator) generateNamedType(path schemaPath, underlying string, body func(g *generator, path schemaPath, name string)) {
+	if path.IsRoot() {
+		fmt.Fprintln(g.output, underlying)
+		body(g, path, pascalToGo(path.CurrentName()))
+		return
+	}
+
+	name := typeNameFromPath(path)
+	fmt.Fprint(g.output, name)
+
+	if schema, ok := g.schemaNamed(name); ok {
+		g.error(fmt.Errorf("type %s collides with schema %q as %s", path, schema, name))
+		return
+	}
+
+	content := g.captured(func(g *generator) {
+		fmt.Fprintf(g.output, "type %s %s\n", name, underlying)
+		body(g, path, name)
+		fmt.Fprint(g.output, "\n\n")
+	})
+	g.state.addGenerated(name, content)
+}
+
+// schemaNamed returns the schema in the document that is generated with the
+// given Go name, if any.
+func (g *generator) schemaNamed(name string) (string, bool) {
+	g.state.Lock()
+	defer g.state.Unlock()
+
+	return g.state.schemaGoName(name)
+}
+
+// generateEnum generates the enum schema at the given path. The enum gets a
+// constant for each value, a String method, a Values function and an IsKnown
+// method.
+func (g *generator) generateEnum(path schemaPath, underlying string, proxies []*openapibase.SchemaProxy) {
+	log := hclog.FromContext(g.state.ctx)
+	log.Debug("generating enum", "path", path.String())
+
+	g.generateNamedType(path, underlying, func(g *generator, path schemaPath, name string) {
+		consts := g.generateConsts(path, name, proxies)
+		g.generateEnumMethods(name, underlying, consts)
+	})
+}
+
+func (g *generator) generateEnumMethods(name, underlying string, consts []enumConst) {
+	// Skip constants with duplicate values, since they can't be told apart.
+	unique := make([]enumConst, 0, len(consts))
+	values := NewSet[string]()
+	for _, c := range consts {
+		if !values.Has(c.Value) {
+			values.Add(c.Value)
+			unique = append(unique, c)
+		}
+	}
+
+	unknownVerb := "%d"
+	if underlying == "string" {
+		unknownVerb = "%q"
+	}
+
+	fmt.Fprintln(g.output)
+	fmt.Fprintf(g.output, "// String returns the name of the constant of v. Unknown values are\n")
+	fmt.Fprintf(g.output, "// formatted as %s(value).\n", name)
+	fmt.Fprintf(g.output, "func (v %s) String() string {\n", name)
+	fmt.Fprintf(g.output, "\tswitch v {\n")
+	for _, c := range unique {
+		fmt.Fprintf(g.output, "\tcase %s:\n", c.Name)
+		fmt.Fprintf(g.output, "\t\treturn %q\n", c.Name)
+	}
+	fmt.Fprintf(g.output, "\t}\n")
+	fmt.Fprintf(g.output, "\treturn fmt.Sprintf(\"%s(%s)\", %s(v))\n", name, unknownVerb, underlying)
+	fmt.Fprintf(g.output, "}\n\n")
+
+	fmt.Fprintf(g.output, "// IsKnown returns whether v is one of the known constants of %s.\n", name)
+	fmt.Fprintf(g.output, "func (v %s) IsKnown() bool {\n", name)
+	fmt.Fprintf(g.output, "\tswitch v {\n")
+	if len(unique) > 0 {
+		fmt.Fprintf(g.output, "\tcase ")
+		for i, c := range unique {
+			if i > 0 {
+				fmt.Fprint(g.output, ", ")
+			}
+			fmt.Fprint(g.output, c.Name)
+		}
+		fmt.Fprintf(g.output, ":\n")
+		fmt.Fprintf(g.output, "\t\treturn true\n")
+	}
+	fmt.Fprintf(g.output, "\t}\n")
+	fmt.Fprintf(g.output, "\treturn false\n")
+	fmt.Fprintf(g.output, "}\n\n")
+
+	fmt.Fprintf(g.output, "// %sValues returns all known values of %[1]s.\n", name)
+	fmt.Fprintf(g.output, "func %sValues() []%[1]s {\n", name)
+	fmt.Fprintf(g.output, "\treturn []%s{\n", name)
+	for _, c := range unique {
+		fmt.Fprintf(g.output, "\t\t%s,\n", c.Name)
+	}
+	fmt.Fprintf(g.output, "\t}\n")
+	fmt.Fprintf(g.output, "}")
+}
+
+// generateDocEnum generates the integer enum at the given path using the values
+// of its documentation table, since the schema has none.
+func (g *generator) generateDocEnum(path schemaPath, underlying string, table docread.EnumTable) {
+	log := hclog.FromContext(g.state.ctx)
+	log.Debug("generating enum from documentation",
+		"path", path.String(),
+		"sections", table.Sections)
+
+	g.setDocSource(path, table.Source)
+	g.generateNamedType(path, underlying, func(g *generator, path schemaPath, name string) {
+		prefix := strings.TrimSuffix(name, "s") // remove plural
+
+		consts := make([]enumConst, 0, len(table.Values))
+		for _, row := range table.Values {
+			v, _ := row.Int()
+			consts = append(consts, enumConst{
+				Name:  docEnumConstName(prefix, row),
+				Value: strconv.FormatInt(v, 10),
+				Doc:   row.Description,
+			})
+		}
+
+		g.writeConsts(name, consts)
+		g.generateEnumMethods(name, underlying, consts)
+	})
+}
+
+// docEnumConstName returns the name of the constant of the given row of a
+// documentation table, e.g. WebhookTypeChannelFollower for Channel Follower.
+func docEnumConstName(prefix string, row docread.EnumTableRow) string {
+	return prefix + constToGo(strings.ReplaceAll(row.Name, " ", "_"))
+}
+
+// docEnumIsInteger returns whether all values of the given documentation table
+// are integers.
+func docEnumIsInteger(table docread.EnumTable) bool {
+	for _, row := range table.Values {
+		if _, err := row.Int(); err != nil {
+			return false
+		}
+	}
+	return len(table.Values) > 0
+}
+
+// docEnumRow returns the row of the given documentation table with the given
+// value, which is a Go literal.
+func docEnumRow(table docread.EnumTable, value string) (docread.EnumTableRow, bool) {
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	for _, row := range table.Values {
+		if row.Value == value {
+			return row, true
+		}
+		if i, err := row.Int(); err == nil && strconv.FormatInt(i, 10) == value {
+			return row, true
+		}
+	}
+	return docread.EnumTableRow{}, false
+}
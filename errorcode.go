@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"libdb.so/arikawa-generator/internal/docread"
+)
+
+var knownErrorCodes *docread.ErrorCodeTable
+
+func scrapeErrorCodeDocs() error {
+	t, err := docread.ScrapeErrorCodesFile(errorCodesDocsFile)
+	if err != nil {
+		return err
+	}
+	knownErrorCodes = t
+	return nil
+}
+
+const apiErrorCode = `// APIError is an error response of the API. Fields of the request that are
+// invalid are described by Errors.
+type APIError struct {
+	Code    ErrorCode        ` + "`json:\"code\"`" + `
+	Message string           ` + "`json:\"message\"`" + `
+	Errors  *APIErrorDetails ` + "`json:\"errors,omitempty\"`" + `
+}
+
+// Error implements error. The errors of the fields are listed after the
+// message.
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Discord API error %d: %s", int(e.Code), e.Message)
+	if e.Errors != nil {
+		e.Errors.walk("", func(path string, err APIFieldError) {
+			fmt.Fprintf(&b, "\n  %s: %s (%s)", path, err.Message, err.Code)
+		})
+	}
+	return b.String()
+}
+
+// APIErrorDetails is the nested object of the errors of the fields of a
+// request. Nested fields are keyed by their names or, within arrays, their
+// indices.
+type APIErrorDetails struct {
+	// Errors are the errors of the field itself.
+	Errors []APIFieldError
+	// Fields are the errors of the nested fields.
+	Fields map[string]*APIErrorDetails
+}
+
+// APIFieldError is an error of a field of a request.
+type APIFieldError struct {
+	Code    string ` + "`json:\"code\"`" + `
+	Message string ` + "`json:\"message\"`" + `
+}
+
+// walk calls f with the dotted path of each error of d and its nested fields,
+// e.g. embeds.0.title.
+func (d *APIErrorDetails) walk(path string, f func(path string, err APIFieldError)) {
+	for _, err := range d.Errors {
+		f(path, err)
+	}
+
+	keys := make([]string, 0, len(d.Fields))
+	for key := range d.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := key
+		if path != "" {
+			child = path + "." + key
+		}
+		d.Fields[key].walk(child, f)
+	}
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (d APIErrorDetails) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]any, len(d.Fields)+1)
+	for key, child := range d.Fields {
+		fields[key] = child
+	}
+	if len(d.Errors) > 0 {
+		fields["_errors"] = d.Errors
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (d *APIErrorDetails) UnmarshalJSON(b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	*d = APIErrorDetails{}
+	for key, v := range fields {
+		if key == "_errors" {
+			if err := json.Unmarshal(v, &d.Errors); err != nil {
+				return err
+			}
+			continue
+		}
+
+		child := new(APIErrorDetails)
+		if err := json.Unmarshal(v, child); err != nil {
+			return err
+		}
+		if d.Fields == nil {
+			d.Fields = make(map[string]*APIErrorDetails)
+		}
+		d.Fields[key] = child
+	}
+	return nil
+}
+
+`
+
+// generateErrorCodes generates the ErrorCode enum using the given error code
+// table, along with the APIError type that carries it.
+func generateErrorCodes(state *generateState, table docread.ErrorCodeTable) {
+	log := hclog.FromContext(state.ctx)
+	log.Debug("generating error codes", "sections", table.Sections)
+
+	var b strings.Builder
+	g := &generator{output: &b, state: state}
+
+	names := NewSet[string]()
+	consts := make([]enumConst, 0, len(table.Codes))
+	for _, row := range table.Codes {
+		name := errorCodeConstName(row.Meaning)
+		if names.Has(name) {
+			// Some meanings only differ after the first clause.
+			name += row.Code
+		}
+		names.Add(name)
+
+		consts = append(consts, enumConst{
+			Name:  name,
+			Value: row.Code,
+			Doc:   row.Meaning,
+		})
+	}
+
+	fmt.Fprintln(g.output, "// ErrorCode is a JSON error code that the API returns in an [APIError].")
+	fmt.Fprintln(g.output, "//")
+	fmt.Fprint(g.output, docSourceComment(filepath.Dir(errorCodesDocsFile), table.Source))
+	fmt.Fprintln(g.output, "type ErrorCode int")
+	g.writeConsts("ErrorCode", consts)
+	g.generateEnumMethods("ErrorCode", "int", consts)
+	fmt.Fprint(g.output, "\n\n")
+
+	state.addGenerated("ErrorCode", b.String())
+	state.addGenerated("APIError", apiErrorCode)
+}
+
+var (
+	parentheticalRe = regexp.MustCompile(`\s*\([^)]*\)`)
+	nonWordRe       = regexp.MustCompile(`[^A-Za-z0-9 ]+`)
+)
+
+// errorCodeConstName returns the name of the constant of the error code with
+// the given meaning, e.g. ErrorCodeUnknownAccount for "Unknown account". Only
+// the first clause of the meaning is used, since some meanings are whole
+// sentences.
+func errorCodeConstName(meaning string) string {
+	meaning = parentheticalRe.ReplaceAllString(meaning, "")
+	if i := strings.IndexAny(meaning, ",.;:"); i != -1 {
+		meaning = meaning[:i]
+	}
+	meaning = nonWordRe.ReplaceAllString(meaning, "")
+	words := strings.Fields(strings.ToLower(meaning))
+	return "ErrorCode" + snakeToGo(strings.Join(words, "_"))
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"libdb.so/arikawa-generator/internal/cmt"
+	"libdb.so/arikawa-generator/internal/docread"
+)
+
+// bitfieldType is the underlying Go type of all generated bitfields.
+const bitfieldType = "uint64"
+
+// flagHelpersCode contains the helpers used by the generated String methods of
+// bitfields. It is generated once if there are any bitfields.
+const flagHelpersCode = `// flagName is the name of a single bit in a bitfield.
+type flagName struct {
+	bit  uint64
+	name string
+}
+
+// formatFlags formats the given bitfield as the names of its set bits joined
+// by "|". Unknown bits are formatted as a single hexadecimal number.
+func formatFlags(f uint64, names []flagName) string {
+	if f == 0 {
+		return "0"
+	}
+
+	var b strings.Builder
+	for _, n := range names {
+		if f&n.bit != n.bit {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('|')
+		}
+		b.WriteString(n.name)
+		f &^= n.bit
+	}
+
+	if f != 0 {
+		if b.Len() > 0 {
+			b.WriteByte('|')
+		}
+		b.WriteString("0x")
+		b.WriteString(strconv.FormatUint(f, 16))
+	}
+
+	return b.String()
+}
+
+`
+
+// schemaIsBitfield returns whether the integer schema at the given path is a
+// bitfield. A bitfield has a name ending in flags, e.g. flags, public_flags or
+// MessageFlags, and constants that are all single bits. Schemas without
+// constants are plain integers, unless their flags are documented.
+func (g *generator) schemaIsBitfield(path schemaPath) bool {
+	if !strings.HasSuffix(strings.ToLower(path.CurrentName()), "flags") {
+		return false
+	}
+
+	schema := path.Current()
+	if len(schema.OneOf) == 0 {
+		_, hasTable := g.docFlags(path)
+		return hasTable
+	}
+
+	for _, proxy := range schema.OneOf {
+		v, err := schemaConst(proxy.Schema())
+		if err != nil {
+			return false
+		}
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || bits.OnesCount64(n) != 1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// generateBitfield generates the bitfield schema at the given path.
+func (g *generator) generateBitfield(path schemaPath) {
+	log := hclog.FromContext(g.state.ctx)
+
+	log.Debug("generating bitfield", "path", path.String())
+
+	g.generateNamedType(path, bitfieldType, (*generator).generateBitfieldBody)
+}
+
+// flagConst is a constant of a single bit in a bitfield.
+type flagConst struct {
+	Name        string
+	Bit         int
+	Doc         string
+	Deprecation string
+}
+
+func (g *generator) generateBitfieldBody(path schemaPath, name string) {
+	g.writeBitfieldBody(name, g.bitfieldFlags(path, name))
+}
+
+// writeBitfieldBody writes the constants of the given flags of the bitfield
+// with the given name along with its methods.
+func (g *generator) writeBitfieldBody(name string, flags []flagConst) {
+	g.state.addGenerated("formatFlags", flagHelpersCode)
+
+	consts := make([]string, len(flags))
+	for i, flag := range flags {
+		consts[i] = flag.Name
+	}
+
+	if len(flags) > 0 {
+		fmt.Fprintln(g.output)
+		fmt.Fprintln(g.output, "const (")
+
+		for _, flag := range flags {
+			if flag.Doc != "" {
+				fmt.Fprint(g.output,
+					g.docComment(flag.Name, flag.Doc, cmt.Opts{Indent: 1}))
+			}
+			if flag.Deprecation != "" {
+				if flag.Doc != "" {
+					fmt.Fprintln(g.output, "\t//")
+				}
+				fmt.Fprintf(g.output, "\t// Deprecated: %s\n", deprecationComment(flag.Deprecation))
+			}
+
+			fmt.Fprintf(g.output, "\t%s %s = 1 << %d\n", flag.Name, name, flag.Bit)
+		}
+
+		fmt.Fprintln(g.output, ")")
+	}
+
+	fmt.Fprintln(g.output)
+	fmt.Fprintln(g.output, "// Has returns whether f has all of the given flags set.")
+	fmt.Fprintf(g.output, "func (f %s) Has(flags %[1]s) bool { return f&flags == flags }\n\n", name)
+	fmt.Fprintln(g.output, "// Add returns f with the given flags set.")
+	fmt.Fprintf(g.output, "func (f %s) Add(flags %[1]s) %[1]s { return f | flags }\n\n", name)
+	fmt.Fprintln(g.output, "// Remove returns f with the given flags cleared.")
+	fmt.Fprintf(g.output, "func (f %s) Remove(flags %[1]s) %[1]s { return f &^ flags }\n\n", name)
+	fmt.Fprintln(g.output, "// Toggle returns f with the given flags flipped.")
+	fmt.Fprintf(g.output, "func (f %s) Toggle(flags %[1]s) %[1]s { return f ^ flags }\n\n", name)
+
+	fmt.Fprintln(g.output, `// String returns the names of the flags set in f joined by "|".`)
+	fmt.Fprintf(g.output, "func (f %s) String() string {\n", name)
+	if len(consts) == 0 {
+		fmt.Fprintln(g.output, "\treturn formatFlags(uint64(f), nil)")
+	} else {
+		fmt.Fprintln(g.output, "\treturn formatFlags(uint64(f), []flagName{")
+		for _, constName := range consts {
+			fmt.Fprintf(g.output, "\t\t{uint64(%s), %q},\n", constName, constName)
+		}
+		fmt.Fprintln(g.output, "\t})")
+	}
+	fmt.Fprint(g.output, "}")
+}
+
+// bitfieldFlags returns the flags of the bitfield schema at the given path.
+// The flags of the schema are documented using the documentation table of the
+// bitfield. If the schema has no flags, then the flags of the table are used.
+func (g *generator) bitfieldFlags(path schemaPath, name string) []flagConst {
+	log := hclog.FromContext(g.state.ctx)
+
+	schema := path.Current()
+	prefix := strings.TrimSuffix(name, "s") // remove plural
+	table, hasTable := g.docFlags(path)
+	if hasTable {
+		g.setDocSource(path, table.Source)
+	}
+
+	docRow := func(bit int) (docread.FlagTableRow, bool) {
+		for _, row := range table.Flags {
+			if row.Value == 1<<bit {
+				return row, true
+			}
+		}
+		return docread.FlagTableRow{}, false
+	}
+
+	var flags []flagConst
+	for _, proxy := range schema.OneOf {
+		schema := proxy.Schema()
+
+		// This is already validated by schemaIsBitfield.
+		v, _ := schemaConst(schema)
+		n, _ := strconv.ParseUint(v, 10, 64)
+
+		flag := flagConst{
+			Name: prefix + constToGo(schema.Title),
+			Bit:  bits.TrailingZeros64(n),
+			Doc:  schema.Description,
+		}
+		if row, ok := docRow(flag.Bit); ok {
+			if flag.Doc == "" {
+				flag.Doc = row.Description
+			}
+			flag.Deprecation = row.Deprecation
+		}
+		flags = append(flags, flag)
+	}
+
+	if len(flags) > 0 || !hasTable {
+		return flags
+	}
+
+	log.Debug("using flags from documentation",
+		"path", path.String(),
+		"sections", table.Sections)
+
+	for _, row := range table.Flags {
+		if bits.OnesCount64(row.Value) != 1 {
+			log.Debug("skipping documented flag that isn't a single bit",
+				"path", path.String(),
+				"flag", row.Name,
+				"value", row.Expr)
+			continue
+		}
+		flags = append(flags, flagConst{
+			Name:        prefix + constToGo(row.Name),
+			Bit:         bits.TrailingZeros64(row.Value),
+			Doc:         row.Description,
+			Deprecation: row.Deprecation,
+		})
+	}
+
+	return flags
+}
+
+var deprecatedPrefixRe = regexp.MustCompile(`(?i)^\**deprecated\**[,:.]?\s*`)
+
+// deprecationComment formats a deprecation note of the documentation as the
+// text after "Deprecated:".
+func deprecationComment(note string) string {
+	note = deprecatedPrefixRe.ReplaceAllString(note, "")
+	if note == "" {
+		return "this flag is deprecated."
+	}
+	if !strings.HasSuffix(note, ".") {
+		note += "."
+	}
+	return note
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"libdb.so/arikawa-generator/internal/cmt"
+	"libdb.so/arikawa-generator/internal/docread"
+)
+
+var knownGatewayEvents []docread.GatewayEvent
+
+func scrapeGatewayDocs() error {
+	e, err := docread.ScrapeGatewayEventsFile(gatewayDocsFile)
+	if err != nil {
+		return err
+	}
+	knownGatewayEvents = append(knownGatewayEvents, e...)
+	return nil
+}
+
+// nonDispatchEvents are the events received from the gateway that are sent
+// with their own opcodes instead of being dispatched, so they have no event
+// name.
+var nonDispatchEvents = NewSet("Hello", "Reconnect", "Invalid Session")
+
+const eventCode = `// Event is an event dispatched by the gateway.
+type Event interface {
+	// EventType returns the name of the event, e.g. MESSAGE_CREATE.
+	EventType() string
+}
+
+// UnknownEvent is an event that has no generated type. Its data is kept as
+// is.
+type UnknownEvent struct {
+	Type string
+	Data json.RawMessage
+}
+
+// EventType implements [Event].
+func (e *UnknownEvent) EventType() string { return e.Type }
+
+// NewEvent returns a new event with the given name, or nil if the event is
+// unknown.
+func NewEvent(eventType string) Event {
+	newEvent, ok := eventConstructors[eventType]
+	if !ok {
+		return nil
+	}
+	return newEvent()
+}
+
+// DecodeEvent decodes the data of the event with the given name. Unknown
+// events are decoded as [UnknownEvent].
+func DecodeEvent(eventType string, data []byte) (Event, error) {
+	ev := NewEvent(eventType)
+	if ev == nil {
+		return &UnknownEvent{
+			Type: eventType,
+			Data: append(json.RawMessage(nil), data...),
+		}, nil
+	}
+	if err := json.Unmarshal(data, ev); err != nil {
+		return nil, fmt.Errorf("cannot decode %s event: %w", eventType, err)
+	}
+	return ev, nil
+}
+
+`
+
+// generateGatewayEvents generates a struct for every dispatch event in the
+// given events, along with a registry that maps the names of the events to
+// their constructors.
+func generateGatewayEvents(state *generateState, events []docread.GatewayEvent) {
+	log := hclog.FromContext(state.ctx)
+
+	var names []string
+	var b strings.Builder
+	for _, event := range events {
+		if nonDispatchEvents.Has(event.Title) {
+			log.Debug("skipping non-dispatch event", "event", event.Title)
+			continue
+		}
+
+		b.Reset()
+		g := &generator{output: &b, state: state}
+		name := g.generateGatewayEvent(event)
+		state.addGenerated(name, b.String())
+		names = append(names, event.Name())
+	}
+
+	state.addGenerated("Event", eventCode)
+
+	b.Reset()
+	b.WriteString("// eventConstructors maps the names of the events to functions that return\n")
+	b.WriteString("// new events.\n")
+	b.WriteString("var eventConstructors = map[string]func() Event{\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "\t%q: func() Event { return new(%s) },\n", name, gatewayEventTypeName(name))
+	}
+	b.WriteString("}\n\n")
+	state.addGenerated("eventConstructors", b.String())
+}
+
+// gatewayEventTypeName returns the Go name of the event with the given name,
+// e.g. MessageCreateEvent for MESSAGE_CREATE.
+func gatewayEventTypeName(name string) string {
+	return snakeToGo(strings.ToLower(name)) + "Event"
+}
+
+// generateGatewayEvent generates the struct of the given event and returns its
+// name. Events whose payload is a documented object embed that object.
+func (g *generator) generateGatewayEvent(event docread.GatewayEvent) string {
+	log := hclog.FromContext(g.state.ctx)
+	log.Debug("generating gateway event", "event", event.Title)
+
+	name := gatewayEventTypeName(event.Name())
+	path := schemaPath{{Name: name}}
+
+	// Descriptions of events with extra fields end with a colon that leads
+	// into the table.
+	doc := "is the " + event.Name() + " event. " + strings.TrimSuffix(event.Description, ":")
+	fmt.Fprint(g.output, g.docComment(name, doc, cmt.Opts{}))
+	fmt.Fprintln(g.output, "//")
+	fmt.Fprint(g.output, docSourceComment(filepath.Dir(gatewayDocsFile), event.Source))
+
+	var rows []docread.ObjectTableRow
+	if event.Fields != nil {
+		rows = event.Fields.Table
+	}
+
+	// See generateStruct.
+	var needsMarshal bool
+
+	fmt.Fprintf(g.output, "type %s struct {\n", name)
+	if event.Payload != "" {
+		page, anchor, _ := strings.Cut(strings.TrimPrefix(event.Payload, "#"), "/")
+		payload, ok := g.docTypeName(page, anchor)
+		if ok {
+			g.state.Lock()
+			_, ok = g.state.structs[payload]
+			g.state.Unlock()
+		}
+		switch {
+		case ok:
+			fmt.Fprintf(g.output, "\t%s\n", payload)
+			needsMarshal = true
+		case len(rows) == 0:
+			// Keep the payload as is, since there's nothing else.
+			fmt.Fprintln(g.output, "\tjson.RawMessage")
+		}
+		if !ok {
+			log.Warn("gateway event payload has no generated struct",
+				"event", event.Title,
+				"payload", event.Payload)
+		}
+	}
+
+	for _, row := range rows {
+		field := strings.TrimSuffix(row.Field, "?")
+		optional := strings.HasSuffix(row.Field, "?")
+
+		fmt.Fprint(g.output, g.docComment(snakeToGo(field), row.Description, cmt.Opts{
+			OriginalName: field,
+			Indent:       1,
+		}))
+
+		t := g.docFieldType(path.Push(field, nil), docread.ParseTypeExpr(row.Type))
+		jsonKey := field
+		if optional {
+			t = optionalType(t)
+			jsonKey += ",omitempty"
+		}
+		if usesOption(t) {
+			needsMarshal = true
+		}
+		fmt.Fprintf(g.output, "\t%s %s `json:%q`\n", snakeToGo(field), t, jsonKey)
+	}
+	fmt.Fprint(g.output, "}\n\n")
+
+	if needsMarshal {
+		fmt.Fprint(g.output, structMarshalCode(name))
+	}
+
+	fmt.Fprintln(g.output, "// EventType implements [Event].")
+	fmt.Fprintf(g.output, "func (*%s) EventType() string { return %q }\n\n", name, event.Name())
+
+	return name
+}
+
+// docPrimitiveTypes maps the primitive types of the documentation to Go types.
+// Snowflakes are guessed from the name of the field instead.
+var docPrimitiveTypes = map[docread.Primitive]string{
+	docread.PrimitiveString:    "string",
+	docread.PrimitiveInteger:   "int",
+	docread.PrimitiveFloat:     "float64",
+	docread.PrimitiveBoolean:   "bool",
+	docread.PrimitiveTimestamp: "time.Time",
+}
+
+// docFieldType returns the Go type of the field at the given path whose type
+// is documented as typ. Types that can't be resolved are kept as raw JSON.
+func (g *generator) docFieldType(path schemaPath, typ docread.TypeExpr) string {
+	var t string
+	switch typ.Kind {
+	case docread.PrimitiveType:
+		if typ.Primitive == docread.PrimitiveSnowflake {
+			t = g.guessSnowflake(path)
+		} else {
+			t = docPrimitiveTypes[typ.Primitive]
+		}
+	case docread.ObjectType, docread.ReferenceType:
+		t, _ = g.docTypeName(typ.Ref.Page, typ.Ref.Anchor)
+	case docread.ArrayType:
+		t = "[]" + g.docFieldType(path.Push("_[]", nil), *typ.Elem)
+	case docread.MapType:
+		// JSON object keys can only be strings or integers.
+		if typ.Key.Kind == docread.PrimitiveType && !typ.Key.Nullable {
+			key := g.docFieldType(path.Push("_key", nil), *typ.Key)
+			t = "map[" + key + "]" + g.docFieldType(path.Push("_{}", nil), *typ.Elem)
+		}
+	}
+
+	if t == "" {
+		log := hclog.FromContext(g.state.ctx)
+		log.Debug("unknown documentation type, using raw JSON",
+			"path", path.String(),
+			"type", typ.Raw)
+
+		t = "json.RawMessage"
+	}
+
+	if typ.Nullable {
+		t = "*" + t
+	}
+	return t
+}
@@ -27,11 +27,31 @@ import (
 	"libdb.so/arikawa-generator/internal/docread"
 
 	openapibase "github.com/pb33f/libopenapi/datamodel/high/base"
+	openapi "github.com/pb33f/libopenapi/datamodel/high/v3"
 )
 
 type generateState struct {
 	sync.Mutex
 	generated map[string]string
+	// schemaNames maps each schema name in the document to the name that it
+	// is generated as.
+	schemaNames map[string]string
+	// responses holds the response components of the document.
+	responses map[string]*openapi.Response
+	// responseNames maps each response component that has its own type to
+	// the name of the type. Names are reserved before the types are
+	// generated.
+	responseNames map[string]string
+	// structs holds the layouts of all named struct types.
+	structs map[string]structLayout
+	// requestTypes holds the names of all types used as request bodies.
+	requestTypes Set[string]
+	// docMisses holds the objects that have no likely documentation table,
+	// keyed by their paths.
+	docMisses map[string]docMiss
+	// docTypes holds the types that are documented by a section of the
+	// documentation. It is only written to before generating.
+	docTypes []docType
 
 	errors     []error
 	errorCount int
@@ -41,8 +61,13 @@ type generateState struct {
 
 func newState(ctx context.Context) *generateState {
 	return &generateState{
-		generated: map[string]string{},
-		ctx:       ctx,
+		generated:     map[string]string{},
+		schemaNames:   map[string]string{},
+		responseNames: map[string]string{},
+		structs:       map[string]structLayout{},
+		requestTypes:  NewSet[string](),
+		docMisses:     map[string]docMiss{},
+		ctx:           ctx,
 	}
 }
 
@@ -84,32 +109,34 @@ func Generate(doc libopenapi.Document, pkgName string) ([]byte, error) {
 		return nil, errors.Wrap(err, "failed to build OpenAPI v3 model")
 	}
 
-	var buf bytes.Buffer
-	buf.WriteString("// Code generated by arikawa-generator. DO NOT EDIT.\n\n")
-	buf.WriteString("package " + pkgName + "\n\n")
-
-	fmt.Fprintf(&buf, "import %q", optionPkg)
-	fmt.Fprintln(&buf)
-	fmt.Fprintln(&buf)
-
 	state := newState(context.TODO())
+	state.responses = v3doc.Model.Components.Responses
+	trimSchemaNames(state, v3doc.Model.Components.Schemas)
 
-	// Trim off "Response" if there's no collision.
-	for name, schema := range v3doc.Model.Components.Schemas {
-		trimmed := strings.TrimSuffix(name, "Response")
-		if _, ok := v3doc.Model.Components.Schemas[trimmed]; !ok {
-			v3doc.Model.Components.Schemas[trimmed] = schema
-			delete(v3doc.Model.Components.Schemas, name)
-		}
+	if withDocs {
+		registerDocTypes(state, v3doc.Model.Components.Schemas)
 	}
 
 	parallelMapAttrsInplace(v3doc.Model.Components.Schemas, state.addGenerated,
 		func(name string, proxy *openapibase.SchemaProxy) string {
 			return generateNamedSchema(state, schemaPath{{Name: name, SchemaProxy: proxy}})
 		})
+	generateClient(state, v3doc.Model.Paths)
+	if withGateway {
+		generateGatewayEvents(state, knownGatewayEvents)
+	}
+	if knownErrorCodes != nil {
+		generateErrorCodes(state, *knownErrorCodes)
+	}
+	generatePermissions(state, knownPermissions)
+	generateSnowflakes(state)
+	generateValidators(state)
+	logDocReport(state)
+
+	var body bytes.Buffer
 	schemaBytesIter := orderedMap(state.generated)
 	schemaBytesIter(func(name, generated string) bool {
-		buf.WriteString(generated)
+		body.WriteString(generated)
 		return true
 	})
 
@@ -118,21 +145,134 @@ func Generate(doc libopenapi.Document, pkgName string) ([]byte, error) {
 		return nil, errors.Wrapf(err, "encountered %d errors such as", state.errorCount)
 	}
 
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by arikawa-generator. DO NOT EDIT.\n\n")
+	buf.WriteString("package " + pkgName + "\n\n")
+	buf.WriteString(generateImports(state.ctx, body.Bytes()))
+	buf.Write(body.Bytes())
+
 	return buf.Bytes(), nil
 }
 
+// trimSchemaNames trims off "Response" from the names of the given schemas if
+// there's no collision. The schemas are renamed in place, and the new names are
+// recorded in the state.
+func trimSchemaNames(state *generateState, schemas map[string]*openapibase.SchemaProxy) {
+	for name, schema := range schemas {
+		state.schemaNames[name] = name
+		trimmed := strings.TrimSuffix(name, "Response")
+		if _, ok := schemas[trimmed]; !ok {
+			schemas[trimmed] = schema
+			delete(schemas, name)
+			state.schemaNames[name] = trimmed
+		}
+	}
+}
+
 type generator struct {
 	output io.Writer
 	state  *generateState
+	// declared is true if the generator has written the whole declaration of
+	// a root schema instead of just its type.
+	declared bool
+	// source is the documentation table of the root schema, if any.
+	source docread.Source
 }
 
 func generateNamedSchema(state *generateState, path schemaPath) string {
 	var b strings.Builder
 	g := &generator{output: &b, state: state}
-	fmt.Fprintf(g.output, "type %s ", pascalToGo(path.CurrentName()))
 	g.generateSchema(path)
-	fmt.Fprintf(g.output, "\n\n")
-	return b.String()
+	if g.declared {
+		return b.String()
+	}
+	return fmt.Sprintf("%stype %s %s\n\n",
+		docSourceComment(documentationDir, g.source), pascalToGo(path.CurrentName()), b.String())
+}
+
+// schemaRefName returns the Go type name of the schema that the given
+// reference points to.
+func (g *generator) schemaRefName(ref string) string {
+	name := stdpath.Base(ref)
+
+	g.state.Lock()
+	renamed, ok := g.state.schemaNames[name]
+	g.state.Unlock()
+
+	if ok {
+		name = renamed
+	}
+	return pascalToGo(name)
+}
+
+// responseRefName returns the Go type name of the response that the given
+// reference points to. The response's JSON schema is used as the type. If that
+// schema isn't itself a reference to a generated schema, then it is generated
+// as a new type named after the response, which must not collide with a
+// schema.
+func (g *generator) responseRefName(ref string) string {
+	name := stdpath.Base(ref)
+
+	response, ok := g.state.responses[name]
+	if !ok {
+		g.error(fmt.Errorf("unknown response %q", ref))
+		return ""
+	}
+
+	media, ok := response.Content["application/json"]
+	if !ok || media.Schema == nil {
+		g.error(fmt.Errorf("response %q has no JSON schema", ref))
+		return ""
+	}
+
+	if proxyIsGeneratedReference(media.Schema) {
+		return g.schemaRefName(media.Schema.GetReference())
+	}
+
+	// Reserve the name before generating, since other workers may refer to
+	// the same response in the meantime.
+	g.state.Lock()
+	goName, reserved := g.state.responseNames[name]
+	if !reserved {
+		goName = responseTypeName(name)
+		if schema, ok := g.state.schemaGoName(goName); ok {
+			g.state.Unlock()
+			g.error(fmt.Errorf("response %q collides with schema %q as %s", ref, schema, goName))
+			return ""
+		}
+		g.state.responseNames[name] = goName
+	}
+	g.state.Unlock()
+
+	if !reserved {
+		g.state.addGenerated(goName, generateNamedSchema(g.state, schemaPath{
+			{Name: goName, SchemaProxy: media.Schema},
+		}))
+	}
+
+	return goName
+}
+
+// responseTypeName returns the Go type name of the response component with the
+// given name. The name always ends with Response, since schemas ending with
+// Response are usually trimmed.
+func responseTypeName(name string) string {
+	goName := pascalToGo(name)
+	if !strings.HasSuffix(goName, "Response") {
+		goName += "Response"
+	}
+	return goName
+}
+
+// schemaGoName returns the name of the schema that is generated as the given
+// Go type name, if any. The state must be locked.
+func (e *generateState) schemaGoName(goName string) (string, bool) {
+	for schema, renamed := range e.schemaNames {
+		if pascalToGo(renamed) == goName {
+			return schema, true
+		}
+	}
+	return "", false
 }
 
 func (g *generator) error(err error) {
@@ -163,11 +303,11 @@ func (g *generator) generateSchema(path schemaPath) {
 	if proxy.IsReference() && !path.IsRoot() {
 		switch ref := proxy.GetReference(); stdpath.Dir(ref) {
 		case pathSchemas:
-			name := stdpath.Base(ref)
-			fmt.Fprintf(g.output, "%s", pascalToGo(name))
+			fmt.Fprintf(g.output, "%s", g.schemaRefType(path, proxy))
 			return
 		case pathResponses:
-			return // TODO
+			fmt.Fprintf(g.output, "%s", g.responseRefName(ref))
+			return
 		default:
 			g.error(fmt.Errorf("unknown reference %q", ref))
 			return
@@ -211,7 +351,7 @@ func (g *generator) generateSchema(path schemaPath) {
 		fmt.Fprintf(g.output, "bool")
 		return
 	case "null":
-		fmt.Fprintf(g.output, "Null")
+		fmt.Fprintf(g.output, "option.Null")
 		return
 	}
 
@@ -219,8 +359,9 @@ func (g *generator) generateSchema(path schemaPath) {
 	case schema.AllOf != nil:
 		g.generateAllOf(path, schema.AllOf)
 		return
-	// case schema.AnyOf != nil:
-	// 	return g.generateAnyOf(path, schema.AnyOf)
+	case schema.AnyOf != nil:
+		g.generateAnyOf(path, schema.AnyOf)
+		return
 	case schema.OneOf != nil:
 		g.generateOneOf(path, schema.OneOf)
 		return
@@ -229,7 +370,6 @@ func (g *generator) generateSchema(path schemaPath) {
 		return
 	}
 
-	// TODO: generate Validate() if !opts.anonymous
 	g.generateUnknown(path)
 }
 
@@ -240,13 +380,24 @@ func (g *generator) generateObject(path schemaPath) {
 	schema := path.Current()
 
 	var docCandidateFields map[string]docread.FieldInfo
-	// if candidates := calculateTopLikelihood(path, schema); len(candidates) > 0 {
-	// 	topCandidate := candidates[0]
-	// 	docCandidateFields = docread.ToFieldMap(topCandidate.FieldInfos())
-	// }
+	if table, ok := g.docTable(path, schema); ok {
+		docCandidateFields = docread.ToFieldMap(table.FieldInfos())
+		g.setDocSource(path, table.Source)
+	}
 
-	fmt.Fprintf(g.output, "struct {\n")
+	g.generateStruct(path, nil, objectProperties(schema), docCandidateFields)
+}
+
+// objectProperty is a property of an object schema.
+type objectProperty struct {
+	Name     string
+	Proxy    *openapibase.SchemaProxy
+	Required bool
+}
 
+// objectProperties returns the properties of the given object schema in the
+// order that they appear in the document.
+func objectProperties(schema *openapibase.Schema) []objectProperty {
 	propertyNames := make([]string, 0, len(schema.Properties))
 	for name := range schema.Properties {
 		propertyNames = append(propertyNames, name)
@@ -265,35 +416,64 @@ func (g *generator) generateObject(path schemaPath) {
 		return propertyLines[propertyNames[i]] < propertyLines[propertyNames[j]]
 	})
 
-	for _, name := range propertyNames {
-		proxy := schema.Properties[name]
-		optional := !slices.Contains(schema.Required, name)
+	properties := make([]objectProperty, len(propertyNames))
+	for i, name := range propertyNames {
+		properties[i] = objectProperty{
+			Name:     name,
+			Proxy:    schema.Properties[name],
+			Required: slices.Contains(schema.Required, name),
+		}
+	}
+	return properties
+}
+
+// generateStruct generates a struct with the given embedded types and
+// properties.
+func (g *generator) generateStruct(path schemaPath, embedded []string, properties []objectProperty, docCandidateFields map[string]docread.FieldInfo) {
+	ptype, _ := extractPrimaryType(path.Current().Type)
+	named := path.IsRoot() && !ptype.Nullable
+	if named {
+		g.state.addStruct(pascalToGo(path.CurrentName()), structLayout{
+			Path:       path,
+			Embedded:   embedded,
+			Properties: properties,
+		})
+	}
+
+	// Embedded types may have a MarshalJSON method that would otherwise be
+	// promoted and drop the other fields.
+	needsMarshal := len(embedded) > 0
+
+	fmt.Fprintf(g.output, "struct {\n")
+
+	for _, name := range embedded {
+		fmt.Fprintf(g.output, "\t%s\n", name)
+	}
+
+	for _, property := range properties {
+		name := property.Name
+		optional := !property.Required
 
 		docField, ok := docCandidateFields[name]
 		if ok {
-			fmt.Fprint(g.output, cmt.Prettify(snakeToGo(name), docField.Comment, cmt.Opts{
+			fmt.Fprint(g.output, g.docComment(snakeToGo(name), docField.Comment, cmt.Opts{
 				OriginalName: name,
 				Indent:       len(path) - 1,
 			}))
 		}
 
 		fmt.Fprintf(g.output, "\t%s ", snakeToGo(name))
-		if optional {
-			fmt.Fprintf(g.output, "option.Optional[")
-		}
 
-		t := g.captured(func(g *generator) { g.generateSchema(path.Push(name, proxy)) })
+		t := g.captured(func(g *generator) { g.generateSchema(path.Push(name, property.Proxy)) })
 		if optional {
-			// Remove pointer from type if the type is already optional.
-			t = strings.TrimPrefix(t, "*")
+			t = optionalType(t)
+		}
+		if optional || usesOption(t) {
+			needsMarshal = true
 		}
 
 		fmt.Fprint(g.output, t)
 
-		if optional {
-			fmt.Fprintf(g.output, "]")
-		}
-
 		jsonKey := name
 		if optional {
 			jsonKey += ",omitempty"
@@ -303,6 +483,40 @@ func (g *generator) generateObject(path schemaPath) {
 	}
 
 	fmt.Fprintf(g.output, "}")
+
+	if named && needsMarshal {
+		name := pascalToGo(path.CurrentName())
+		g.state.addGenerated(name+".MarshalJSON", structMarshalCode(name))
+	}
+}
+
+// usesOption returns whether the given type contains optional values, such as
+// the fields of an inline struct.
+func usesOption(t string) bool {
+	return strings.Contains(t, "option.Optional[") || strings.Contains(t, "option.Nullable[")
+}
+
+// structMarshalCode returns the MarshalJSON method of the named struct. Since
+// optional values are structs, encoding/json doesn't omit them when they're
+// unset, so the method uses [option.MarshalStruct] to do that.
+func structMarshalCode(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// MarshalJSON implements [json.Marshaler]. Unset optional fields are\n")
+	fmt.Fprintf(&b, "// omitted.\n")
+	fmt.Fprintf(&b, "func (v %s) MarshalJSON() ([]byte, error) {\n", name)
+	fmt.Fprintf(&b, "\treturn option.MarshalStruct(v)\n")
+	fmt.Fprintf(&b, "}\n\n")
+	return b.String()
+}
+
+// optionalType returns the type of an optional field of the given type.
+// Optional nullable fields can be absent, null or a value, so they need all
+// three states instead of a pointer.
+func optionalType(t string) string {
+	if nullable := strings.HasPrefix(t, "*"); nullable {
+		return "option.Nullable[" + strings.TrimPrefix(t, "*") + "]"
+	}
+	return "option.Optional[" + t + "]"
 }
 
 func (g *generator) generateString(path schemaPath) error {
@@ -310,16 +524,21 @@ func (g *generator) generateString(path schemaPath) error {
 	log.Debug("generating string", "path", path.String())
 
 	schema := path.Current()
+	if isPermissionsField(path) {
+		fmt.Fprintf(g.output, "Permissions")
+		return nil
+	}
+
 	switch schema.Format {
 	case "snowflake":
 		fmt.Fprintf(g.output, "%s", g.guessSnowflake(path))
 	case "date-time":
 		fmt.Fprintf(g.output, "time.Time")
 	default:
-		fmt.Fprintf(g.output, "string")
 		if len(schema.OneOf) > 0 {
-			fmt.Fprintln(g.output)
-			g.generateConsts(path, schema.OneOf)
+			g.generateEnum(path, "string", schema.OneOf)
+		} else {
+			fmt.Fprintf(g.output, "string")
 		}
 	}
 
@@ -338,7 +557,7 @@ func (g *generator) guessSnowflake(path schemaPath) string {
 	fieldName := snakeToGo(path.CurrentName())
 	parentName := pascalToGo(path.Parent().CurrentName())
 
-	for kind := range snowflakes {
+	for _, kind := range sortedSnowflakeKinds() {
 		if false ||
 			(kind+"ID" == fieldName) ||
 			(kind+"IDs" == fieldName) ||
@@ -369,13 +588,13 @@ func (g *generator) generateInteger(path schemaPath) {
 
 	var intType string
 	if len(schema.AllOf) == 1 && proxyIsGeneratedReference(schema.AllOf[0]) {
-		ref := schema.AllOf[0].GetReference()
-		intType = pascalToGo(stdpath.Base(ref))
+		intType = g.schemaRefName(schema.AllOf[0].GetReference())
 	}
-	if intType == "" && enumNames.Has(path.CurrentName()) {
-		log := hclog.FromContext(g.state.ctx)
-		log.Warn(path.CurrentName()+" is integer but should be enum", "path", path.String())
+	if intType == "" && g.schemaIsBitfield(path) {
+		g.generateBitfield(path)
+		return
 	}
+	referenced := intType != ""
 	if intType == "" && schema.Format != "" {
 		intType = schema.Format
 	}
@@ -383,23 +602,45 @@ func (g *generator) generateInteger(path schemaPath) {
 		intType = "int"
 	}
 
-	fmt.Fprintf(g.output, "%s", intType)
-
 	if len(schema.OneOf) > 0 {
-		g.generateConsts(path, schema.OneOf)
+		g.generateEnum(path, intType, schema.OneOf)
+		return
+	}
+
+	if !referenced {
+		if table, ok := g.docEnum(path); ok && docEnumIsInteger(table) {
+			g.generateDocEnum(path, intType, table)
+			return
+		}
+		if enumNames.Has(path.CurrentName()) {
+			log.Warn(path.CurrentName()+" is integer but should be enum", "path", path.String())
+		}
 	}
+
+	fmt.Fprintf(g.output, "%s", intType)
+}
+
+// enumConst is a constant generated for an enum value.
+type enumConst struct {
+	Name  string
+	Value string
+	Doc   string
 }
 
-func (g *generator) generateConsts(path schemaPath, proxies []*openapibase.SchemaProxy) {
+// generateConsts generates the constants of the enum type with the given name
+// and returns them. Constants without a description are documented using the
+// documentation table of the enum, if any.
+func (g *generator) generateConsts(path schemaPath, typeName string, proxies []*openapibase.SchemaProxy) []enumConst {
 	log := hclog.FromContext(g.state.ctx)
 	log.Debug("generating consts", "path", path.String())
 
-	fmt.Fprintln(g.output)
-	fmt.Fprintln(g.output, "const (")
-
-	prefix := pascalToGo(path.CurrentName())
-	prefix = strings.TrimSuffix(prefix, "s") // remove plural
+	prefix := strings.TrimSuffix(typeName, "s") // remove plural
+	table, hasTable := g.docEnum(path)
+	if hasTable {
+		g.setDocSource(path, table.Source)
+	}
 
+	consts := make([]enumConst, 0, len(proxies))
 	for _, proxy := range proxies {
 		schema := proxy.Schema()
 
@@ -411,82 +652,295 @@ func (g *generator) generateConsts(path schemaPath, proxies []*openapibase.Schem
 			continue
 		}
 
-		if schema.Description != "" {
-			fmt.Fprint(g.output,
-				cmt.Prettify(constName, schema.Description, cmt.Opts{Indent: 1}))
+		doc := schema.Description
+		if hasTable {
+			// The documentation names and documents the values better than
+			// the titles of the schema.
+			if row, ok := docEnumRow(table, constVal); ok {
+				constName = docEnumConstName(prefix, row)
+				if row.Description != "" {
+					doc = row.Description
+				}
+			}
 		}
 
-		fmt.Fprintf(g.output,
-			"\t%s %s = %s\n",
-			constName, pascalToGo(path.CurrentName()), constVal)
+		consts = append(consts, enumConst{Name: constName, Value: constVal, Doc: doc})
 	}
 
+	g.writeConsts(typeName, consts)
+	return consts
+}
+
+// writeConsts writes a const block of the given constants of the given type.
+func (g *generator) writeConsts(typeName string, consts []enumConst) {
+	fmt.Fprintln(g.output)
+	fmt.Fprintln(g.output, "const (")
+	for _, c := range consts {
+		if c.Doc != "" {
+			fmt.Fprint(g.output, g.docComment(c.Name, c.Doc, cmt.Opts{Indent: 1}))
+		}
+		fmt.Fprintf(g.output, "\t%s %s = %s\n", c.Name, typeName, c.Value)
+	}
 	fmt.Fprintln(g.output, ")")
 }
 
+// generateAllOf generates an allOf schema by composing all of its schemas into
+// a single struct. Referenced schemas are embedded, while the properties of
+// inlined object schemas are merged. A single schema is generated as itself.
 func (g *generator) generateAllOf(path schemaPath, proxies []*openapibase.SchemaProxy) {
 	log := hclog.FromContext(g.state.ctx)
 	log.Debug("generating allOf", "path", path.String())
 
-	fmt.Fprintln(g.output, "struct {")
+	type member struct {
+		path  schemaPath
+		proxy *openapibase.SchemaProxy
+	}
+
+	members := make([]member, 0, len(proxies))
 	for i, proxy := range proxies {
-		g.generateSchema(path.Push(fmt.Sprintf("_allOf[%d]", i), proxy))
+		if !proxyIsGeneratedReference(proxy) && schemaIsAnnotation(proxy.Schema()) {
+			// Skip schemas that only annotate the allOf, e.g. with a
+			// description.
+			continue
+		}
+		members = append(members, member{
+			path:  path.Push(fmt.Sprintf("_allOf[%d]", i), proxy),
+			proxy: proxy,
+		})
+	}
+
+	switch len(members) {
+	case 0:
+		g.generateUnknown(path)
+		return
+	case 1:
+		g.generateSchema(members[0].path)
+		return
+	}
+
+	var embedded []string
+	var properties []objectProperty
+	// owners maps each property to the name of the member that it comes
+	// from, which is used to report conflicts.
+	owners := map[string]string{}
+	// required is the union of the required properties of all members.
+	required := NewSet[string]()
+
+	addProperty := func(owner string, property objectProperty) {
+		i := slices.IndexFunc(properties, func(p objectProperty) bool {
+			return p.Name == property.Name
+		})
+		if i == -1 {
+			properties = append(properties, property)
+			owners[property.Name] = owner
+			return
+		}
+
+		if properties[i].Proxy.Schema().GoLow().Hash() != property.Proxy.Schema().GoLow().Hash() {
+			g.error(fmt.Errorf("allOf %s has conflicting property %q in %s and %s",
+				path, property.Name, owners[property.Name], owner))
+		}
+		properties[i].Required = properties[i].Required || property.Required
+	}
+
+	// refs maps the members that are embedded to the names of their types.
+	refs := map[int]string{}
+	// sources maps each property to the members that have it.
+	sources := map[string][]int{}
+	// skipped holds the members that can't be composed.
+	skipped := NewSet[int]()
+
+	for i, member := range members {
+		schema := member.proxy.Schema()
+
+		if proxyIsGeneratedReference(member.proxy) {
+			if g.proxyIsUnion(member.path, member.proxy) {
+				g.error(fmt.Errorf("allOf %s cannot embed union %s", path, member.proxy.GetReference()))
+				skipped.Add(i)
+				continue
+			}
+			refs[i] = g.schemaRefName(member.proxy.GetReference())
+		} else if !slices.Equal(schema.Type, []string{"object"}) && len(schema.Properties) == 0 {
+			g.error(fmt.Errorf("allOf %s has non-object schema %s", path, member.path))
+			skipped.Add(i)
+			continue
+		}
+
+		for _, property := range objectProperties(schema) {
+			sources[property.Name] = append(sources[property.Name], i)
+		}
+		// Members may also require properties of other members.
+		required.Add(schema.Required...)
+	}
+
+	// Members whose properties are shared with other members are merged
+	// instead of embedded, since encoding/json ignores fields that are
+	// ambiguous at the same depth. So are members whose properties are
+	// required by other members, since their types don't require them.
+	flattened := NewSet[int]()
+	for name, ixs := range sources {
+		if len(ixs) > 1 {
+			flattened.Add(ixs...)
+			continue
+		}
+		if !slices.Contains(members[ixs[0]].proxy.Schema().Required, name) && required.Has(name) {
+			flattened.Add(ixs[0])
+		}
+	}
+
+	for i, member := range members {
+		if skipped.Has(i) {
+			continue
+		}
+
+		owner := member.path.String()
+		if name, ok := refs[i]; ok {
+			if !flattened.Has(i) {
+				embedded = append(embedded, name)
+				continue
+			}
+			owner = name
+		}
+
+		for _, property := range objectProperties(member.proxy.Schema()) {
+			addProperty(owner, property)
+		}
+	}
+
+	for i := range properties {
+		properties[i].Required = properties[i].Required || required.Has(properties[i].Name)
 	}
-	fmt.Fprintln(g.output, "}")
+
+	g.generateStruct(path, embedded, properties, nil)
+}
+
+// schemaIsAnnotation returns whether the given schema only contains
+// annotations such as a description and no actual type information.
+func schemaIsAnnotation(schema *openapibase.Schema) bool {
+	return len(schema.Type) == 0 &&
+		len(schema.Properties) == 0 &&
+		schema.AllOf == nil &&
+		schema.AnyOf == nil &&
+		schema.OneOf == nil &&
+		schema.Not == nil &&
+		schema.Items == nil
 }
 
-func (g *generator) generateAnyOf(path schemaPath, proxies []*openapibase.SchemaProxy) error {
+// generateAnyOf generates an anyOf schema. A JSON value may match more than
+// one of the schemas at once, so if all schemas are objects, then they are
+// merged into a single struct. Otherwise, a value can only ever be one of the
+// schemas, so it is generated the same way as a oneOf.
+func (g *generator) generateAnyOf(path schemaPath, proxies []*openapibase.SchemaProxy) {
 	log := hclog.FromContext(g.state.ctx)
 	log.Debug("generating anyOf", "path", path.String())
 
-	return nil
-}
+	properties, ok := g.mergeAnyOfProperties(path, proxies)
+	if !ok {
+		g.generateOneOf(path, proxies)
+		return
+	}
 
-func (g *generator) generateOneOf(path schemaPath, proxies []*openapibase.SchemaProxy) error {
-	log := hclog.FromContext(g.state.ctx)
+	g.generateStruct(path, nil, properties, nil)
+}
 
-	// Special case: Discord gives [{type: null}, T].
-	if len(proxies) == 2 {
-		nullIx := slices.IndexFunc(proxies, func(proxy *openapibase.SchemaProxy) bool {
-			schema := proxy.Schema()
-			return slices.Equal(schema.Type, []string{"null"})
-		})
-		if nullIx != -1 {
-			fmt.Fprintf(g.output, "*")
-			i := 1 - nullIx
-			g.generateSchema(path.Push(fmt.Sprintf("_oneOf[%d]", i), proxies[i]))
-			return nil
+// mergeAnyOfProperties merges the properties of all the given object schemas.
+// A property is only required if all schemas require it, since the value may
+// match any of them. False is returned if not all schemas are objects or if a
+// property has conflicting types.
+func (g *generator) mergeAnyOfProperties(path schemaPath, proxies []*openapibase.SchemaProxy) ([]objectProperty, bool) {
+	var merged []objectProperty
+	for i, proxy := range proxies {
+		schema := proxy.Schema()
+		if !slices.Equal(schema.Type, []string{"object"}) {
+			return nil, false
 		}
-	}
 
-	var name string
-	if path.CurrentIsExported() {
-		name = pascalToGo(path.CurrentName())
-	} else {
-		for _, part := range path {
-			if part.IsPrivate() {
+		properties := objectProperties(schema)
+		for _, property := range properties {
+			j := slices.IndexFunc(merged, func(p objectProperty) bool {
+				return p.Name == property.Name
+			})
+			if j == -1 {
+				// Properties that are missing from a previous schema can't be
+				// required.
+				property.Required = property.Required && i == 0
+				merged = append(merged, property)
 				continue
 			}
-			name += strcases.Go(part.Name)
+
+			if merged[j].Proxy.Schema().GoLow().Hash() != property.Proxy.Schema().GoLow().Hash() {
+				log := hclog.FromContext(g.state.ctx)
+				log.Debug("anyOf has conflicting property, generating as oneOf",
+					"path", path.String(),
+					"property", property.Name)
+				return nil, false
+			}
+
+			merged[j].Required = merged[j].Required && property.Required
+		}
+
+		// Properties that are missing from this schema can't be required.
+		for j := range merged {
+			if !slices.ContainsFunc(properties, func(p objectProperty) bool {
+				return p.Name == merged[j].Name
+			}) {
+				merged[j].Required = false
+			}
 		}
 	}
 
-	log.Debug("generating oneOf", "path", path, "name", name)
+	return merged, true
+}
 
-	// Generate this as a reference to a type, but we'll generate the type
-	// globally.
-	fmt.Fprint(g.output, name)
+func (g *generator) generateOneOf(path schemaPath, proxies []*openapibase.SchemaProxy) error {
+	log := hclog.FromContext(g.state.ctx)
+
+	// Special case: Discord gives [{type: null}, T].
+	if nullIx := oneOfNullIndex(proxies); nullIx != -1 {
+		fmt.Fprintf(g.output, "*")
+		i := 1 - nullIx
+		g.generateSchema(path.Push(fmt.Sprintf("_oneOf[%d]", i), proxies[i]))
+		return nil
+	}
+
+	name := typeNameFromPath(path)
+	log.Debug("generating oneOf", "path", path, "name", name)
 
 	content := g.captured(func(g *generator) { g.generateNamedOneOf(path, name, proxies) })
 	if path.IsRoot() {
+		// We're generating the named type itself, so write the whole
+		// declaration.
 		fmt.Fprint(g.output, content)
+		g.declared = true
 	} else {
+		// Generate this as a reference to a type, but we'll generate the type
+		// globally. The Wrap type is used since the interface itself can't be
+		// decoded.
+		fmt.Fprint(g.output, name+"Wrap")
 		g.state.addGenerated(name, content)
 	}
 
 	return nil
 }
 
+// typeNameFromPath returns the name of a type that is generated globally for
+// the schema at the given path. Unexported names are prefixed with the names of
+// their parents.
+func typeNameFromPath(path schemaPath) string {
+	if path.CurrentIsExported() {
+		return pascalToGo(path.CurrentName())
+	}
+
+	var name string
+	for _, part := range path {
+		if part.IsPrivate() {
+			continue
+		}
+		name += strcases.Go(part.Name)
+	}
+	return name
+}
+
 func (g *generator) generateNamedOneOf(path schemaPath, unionName string, proxies []*openapibase.SchemaProxy) {
 	names := make([]string, len(proxies))
 
@@ -494,7 +948,7 @@ func (g *generator) generateNamedOneOf(path schemaPath, unionName string, proxie
 	comment.WriteString("is a union of the following types:\n")
 	for i, proxy := range proxies {
 		if proxyIsGeneratedReference(proxy) {
-			names[i] = pascalToGo(stdpath.Base(proxy.GetReference()))
+			names[i] = g.schemaRefName(proxy.GetReference())
 			goto named
 		}
 
@@ -524,6 +978,8 @@ func (g *generator) generateNamedOneOf(path schemaPath, unionName string, proxie
 	}
 	fmt.Fprintln(g.output)
 
+	g.generateUnionJSON(path, unionName, names, proxies)
+
 	// Generate all inlined types.
 	for i, proxy := range proxies {
 		if proxyIsGeneratedReference(proxy) {
@@ -580,10 +1036,9 @@ func proxyIsGeneratedReference(proxy *openapibase.SchemaProxy) bool {
 //
 // Usage:
 //
-//    for k, v := range orderedMap(m) {
-//    	// ...
-//    }
-//
+//	for k, v := range orderedMap(m) {
+//		// ...
+//	}
 func orderedMap[K constraints.Ordered, V any](m map[K]V) func(func(K, V) bool) bool {
 	keys := make([]K, 0, len(m))
 	for k := range m {
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/pb33f/libopenapi"
+	"libdb.so/arikawa-generator/internal/docread"
+)
+
+const inlineResponseSpec = `{
+	"openapi": "3.1.0",
+	"info": {"title": "test", "version": "1"},
+	"paths": {
+		"/batches/{batch_id}": {
+			"post": {
+				"operationId": "run_batch",
+				"parameters": [
+					{"name": "batch_id", "in": "path", "required": true, "schema": {"type": "string"}}
+				],
+				"responses": {
+					"200": {
+						"description": "",
+						"content": {"application/json": {"schema": {"$ref": "#/components/schemas/BatchResult"}}}
+					},
+					"4XX": {"$ref": "#/components/responses/ErrorThing"}
+				}
+			}
+		}
+	},
+	"components": {
+		"schemas": {
+			"ErrorThing": {
+				"type": "object",
+				"properties": {"code": {"type": "integer"}},
+				"required": ["code"]
+			},
+			"BatchResult": {
+				"type": "object",
+				"properties": {
+					"error": {"$ref": "#/components/responses/ErrorThing"},
+					"rate_limit": {"$ref": "#/components/responses/RateLimitedResponse"}
+				},
+				"required": ["error"]
+			}
+		},
+		"responses": {
+			"ErrorThing": {
+				"description": "",
+				"content": {"application/json": {"schema": {
+					"type": "object",
+					"properties": {"message": {"type": "string"}},
+					"required": ["message"]
+				}}}
+			},
+			"RateLimitedResponse": {
+				"description": "",
+				"content": {"application/json": {"schema": {
+					"type": "object",
+					"properties": {"retry_after": {"type": "number"}},
+					"required": ["retry_after"]
+				}}}
+			}
+		}
+	}
+}`
+
+func TestGenerateInlineResponse(t *testing.T) {
+	code := generateTestCode(t, inlineResponseSpec)
+	assert.Contains(t, string(code), "type ErrorThing struct")
+	assert.Contains(t, string(code), "type ErrorThingResponse struct")
+	assert.Contains(t, string(code), "type RateLimitedResponse struct")
+	vetTestCode(t, code)
+}
+
+// generateTestCode generates the code for the given OpenAPI document.
+func generateTestCode(t *testing.T, spec string) []byte {
+	t.Helper()
+
+	doc, err := libopenapi.NewDocument([]byte(spec))
+	assert.NoError(t, err)
+
+	code, err := Generate(doc, "out")
+	assert.NoError(t, err)
+	return code
+}
+
+// vetTestCode runs go vet on the given generated code in a temporary module
+// that uses the option package of this module.
+func vetTestCode(t *testing.T, code []byte) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping test that runs the go command in short mode")
+	}
+
+	root, err := os.Getwd()
+	assert.NoError(t, err)
+
+	sum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	mod := "module out\n\ngo 1.20\n\n" +
+		"require libdb.so/arikawa-generator v0.0.0\n\n" +
+		"replace libdb.so/arikawa-generator => " + root + "\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "go.sum"), sum, 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "out.go"), code, 0o644))
+
+	cmd := exec.Command("go", "vet", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(out))
+}
+
+const bitfieldSpec = `{
+	"openapi": "3.1.0",
+	"info": {"title": "test", "version": "1"},
+	"paths": {},
+	"components": {
+		"schemas": {
+			"Thing": {
+				"type": "object",
+				"properties": {
+					"flags": {"type": "integer"},
+					"public_flags": {
+						"type": "integer",
+						"oneOf": [
+							{"title": "STAFF", "const": 1},
+							{"title": "PARTNER", "const": 2}
+						]
+					}
+				},
+				"required": ["flags", "public_flags"]
+			}
+		}
+	}
+}`
+
+func TestGenerateBitfields(t *testing.T) {
+	code := generateTestCode(t, bitfieldSpec)
+	assert.Contains(t, string(code), "Flags int `json:\"flags\"`")
+	assert.Contains(t, string(code), "PublicFlags ThingPublicFlags `json:\"public_flags\"`")
+	assert.Contains(t, string(code), "ThingPublicFlagStaff ThingPublicFlags = 1 << 0")
+	vetTestCode(t, code)
+}
+
+func TestGenerateBitfieldCollision(t *testing.T) {
+	spec := strings.Replace(bitfieldSpec, `"schemas": {`,
+		`"schemas": {"ThingPublicFlags": {"type": "string"},`, 1)
+
+	doc, err := libopenapi.NewDocument([]byte(spec))
+	assert.NoError(t, err)
+
+	_, err = Generate(doc, "out")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `collides with schema "ThingPublicFlags"`)
+}
+
+func TestGenerateSnowflakes(t *testing.T) {
+	code := generateTestCode(t, `{
+		"openapi": "3.1.0",
+		"info": {"title": "test", "version": "1"},
+		"paths": {},
+		"components": {"schemas": {}}
+	}`)
+	assert.Contains(t, string(code), "type Snowflake uint64")
+	assert.Contains(t, string(code), "type PrimarySKUID Snowflake")
+	assert.NotContains(t, string(code), "IDID")
+}
+
+const requestValidatorSpec = `{
+	"openapi": "3.1.0",
+	"info": {"title": "test", "version": "1"},
+	"paths": {
+		"/things": {
+			"post": {
+				"operationId": "create_thing",
+				"requestBody": {
+					"content": {"application/json": {"schema": {
+						"type": "object",
+						"properties": {"name": {"type": "string", "maxLength": 100}},
+						"required": ["name"]
+					}}}
+				},
+				"responses": {"204": {"description": ""}}
+			},
+			"patch": {
+				"operationId": "update_thing",
+				"requestBody": {
+					"content": {"application/json": {"schema": {
+						"type": "object",
+						"properties": {"name": {"type": "string"}},
+						"required": ["name"]
+					}}}
+				},
+				"responses": {"204": {"description": ""}}
+			}
+		}
+	},
+	"components": {"schemas": {}}
+}`
+
+func TestGenerateRequestValidators(t *testing.T) {
+	code := generateTestCode(t, requestValidatorSpec)
+	assert.Contains(t, string(code), "func (v CreateThingRequest) Validate() error {\n\treturn errors.Join(")
+	assert.Contains(t, string(code), "func (v UpdateThingRequest) Validate() error {\n\treturn nil\n}")
+	vetTestCode(t, code)
+}
+
+func TestGenerateDocumentedBitfield(t *testing.T) {
+	withDocs, knownDocFlags = true, []docread.FlagTable{{
+		Sections: []string{"Thing", "Thing Flags"},
+		Flags: []docread.FlagTableRow{
+			{Name: "HIDDEN", Value: 1 << 2, Expr: "1 << 2"},
+		},
+	}}
+	t.Cleanup(func() { withDocs, knownDocFlags = false, nil })
+
+	code := generateTestCode(t, bitfieldSpec)
+	assert.Contains(t, string(code), "Flags ThingFlags `json:\"flags\"`")
+	assert.Contains(t, string(code), "ThingFlagHidden ThingFlags = 1 << 2")
+	vetTestCode(t, code)
+}
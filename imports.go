@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// knownImports returns the import paths of all packages that generated code
+// may use, keyed by their package names.
+func knownImports() map[string]string {
+	return map[string]string{
+		"context": "context",
+		"errors":  "errors",
+		"fmt":     "fmt",
+		"json":    "encoding/json",
+		"regexp":  "regexp",
+		"sort":    "sort",
+		"strconv": "strconv",
+		"strings": "strings",
+		"time":    "time",
+		"url":     "net/url",
+		"utf8":    "unicode/utf8",
+		"option":  optionPkg,
+	}
+}
+
+// generateImports returns the import block for the given generated code, which
+// must not contain a package clause or any imports. Only the packages that the
+// code uses are imported.
+func generateImports(ctx context.Context, code []byte) string {
+	known := knownImports()
+	used := NewSet[string]()
+
+	for _, name := range usedPackageNames(ctx, code) {
+		if path, ok := known[name]; ok {
+			used.Add(path)
+		}
+	}
+
+	if len(used) == 0 {
+		return ""
+	}
+
+	var std, others []string
+	for path := range used {
+		// Standard library packages don't have a domain.
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			others = append(others, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, path := range std {
+		b.WriteString("\t\"" + path + "\"\n")
+	}
+	if len(std) > 0 && len(others) > 0 {
+		b.WriteString("\n")
+	}
+	for _, path := range others {
+		b.WriteString("\t\"" + path + "\"\n")
+	}
+	b.WriteString(")\n\n")
+	return b.String()
+}
+
+var selectorRe = regexp.MustCompile(`\b([a-z]+)\.[A-Z]`)
+
+// usedPackageNames returns the names of all packages that the given code
+// refers to. These are the identifiers used in selector expressions that are
+// not declared anywhere in the code.
+func usedPackageNames(ctx context.Context, code []byte) []string {
+	src := append([]byte("package generated\n\n"), code...)
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		// The code is invalid, which is reported when it's formatted. Just
+		// guess the packages from the code so the output is still mostly
+		// usable.
+		log := hclog.FromContext(ctx)
+		log.Warn("cannot parse generated code to find imports", "error", err)
+
+		var names []string
+		for _, match := range selectorRe.FindAllSubmatch(code, -1) {
+			names = append(names, string(match[1]))
+		}
+		return names
+	}
+
+	var names []string
+	ast.Inspect(f, func(node ast.Node) bool {
+		sel, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		// Unresolved identifiers are either packages or undeclared names.
+		if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+			names = append(names, ident.Name)
+		}
+		return true
+	})
+	return names
+}
@@ -45,21 +45,50 @@ func (r ObjectTableRow) FieldInfo() FieldInfo {
 	var f FieldInfo
 	f.Name = strings.TrimSuffix(r.Field, "?")
 	f.Type = strings.TrimPrefix(r.Type, "?")
+	f.TypeExpr = ParseTypeExpr(r.Type)
 	f.Comment = r.Description
-	f.Optional = strings.HasSuffix(f.Name, "?")
-	f.Nullable = strings.HasPrefix(f.Type, "?")
+	f.Optional = strings.HasSuffix(r.Field, "?")
+	f.Nullable = strings.HasPrefix(r.Type, "?")
 	return f
 }
 
 // Source describes where a table came from.
 type Source struct {
-	Path     string
+	// Path is the path to the documentation file relative to the scraped
+	// directory. It is empty for tables scraped using the ScrapeBytes
+	// functions.
+	Path string
+	// Line is the 1-based line number of the table's header.
+	Line int
+	// Anchor is the anchor of the table's section, e.g.
+	// webhook-object-webhook-structure.
+	Anchor string
+	// Position is the byte offset of the table's header.
 	Position int
 }
 
+// String formats the source as a path with a line fragment, e.g.
+// resources/Webhook.md#L42.
+func (s Source) String() string {
+	return fmt.Sprintf("%s#L%d", s.Path, s.Line)
+}
+
+func newSource(path string, src []byte, pos int, sections []string) Source {
+	return Source{
+		Path:     path,
+		Line:     bytes.Count(src[:pos], []byte{'\n'}) + 1,
+		Anchor:   SectionAnchor(sections),
+		Position: pos,
+	}
+}
+
 // ScrapeFS scrapes the documentation filesystem for object tables.
 func ScrapeFS(dir fs.FS) ([]ObjectTable, error) {
-	var tables []ObjectTable
+	return scrapeFS(dir, scrapeBytes)
+}
+
+func scrapeFS[T any](dir fs.FS, scrape func(path string, b []byte) ([]T, error)) ([]T, error) {
+	var tables []T
 	err := fs.WalkDir(dir, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -73,7 +102,7 @@ func ScrapeFS(dir fs.FS) ([]ObjectTable, error) {
 			return err
 		}
 
-		t, err := scrapeBytes(path, b)
+		t, err := scrape(path, b)
 		if err != nil {
 			return err
 		}
@@ -98,28 +127,64 @@ func ScrapeBytes(b []byte) ([]ObjectTable, error) {
 func scrapeBytes(path string, b []byte) ([]ObjectTable, error) {
 	var tables []ObjectTable
 	for _, pos := range tableHeaderRe.FindAllIndex(b, -1) {
-		t, err := readTable(b, pos[0])
+		t, err := readTable(path, b, pos[0])
 		if err != nil {
 			return nil, fmt.Errorf("cannot read table at %d: %w", pos[0], err)
 		}
 		tables = append(tables, *t)
 	}
-	for i := range tables {
-		tables[i].Source.Path = path
-	}
 	return tables, nil
 }
 
 var (
-	rowRe    = regexp.MustCompile(`(?m)^\| (.*?) *\| (.*?) *\| (.*?) *\|$`)
+	// rowRes match table rows. They're keyed by the number of columns.
+	rowRes = map[int]*regexp.Regexp{
+		2: newRowRe(2),
+		3: newRowRe(3),
+		4: newRowRe(4),
+	}
 	rowSepRe = regexp.MustCompile(`(?m)^\| -* \| -* \| -* \|$`)
 	headerRe = regexp.MustCompile(`(?m)^(#{1,6}) +(.*)$`)
 )
 
-func readTable(src []byte, pos int) (*ObjectTable, error) {
+// newRowRe returns a regular expression that matches a table row with the
+// given number of columns.
+func newRowRe(columns int) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^\|` + strings.Repeat(` (.*?) *\|`, columns) + `$`)
+}
+
+func readTable(path string, src []byte, pos int) (*ObjectTable, error) {
+	var tableRows []ObjectTableRow
+	for _, cols := range readRows(src, pos, 3) {
+		tableRows = append(tableRows, ObjectTableRow{
+			Field:       cols[0],
+			Type:        cols[1],
+			Description: cols[2],
+		})
+	}
+
+	sections, description, err := readSection(src, pos, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectTable{
+		Sections:    sections,
+		Description: description,
+		Table:       tableRows,
+		Source:      newSource(path, src, pos, sections),
+	}, nil
+}
+
+// readRows reads the rows of the table at pos, which has the given number of
+// columns. The header and the separator are skipped, and the columns are
+// trimmed.
+func readRows(src []byte, pos, columns int) [][]string {
+	rowRe := rowRes[columns]
+
 	// Scan until we cannot match a table row.
 	scanner := bufio.NewScanner(bytes.NewReader(src[pos:]))
-	var tableRows []ObjectTableRow
+	var rows [][]string
 	var scanned int
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -134,22 +199,28 @@ func readTable(src []byte, pos int) (*ObjectTable, error) {
 			continue
 		}
 
+		cols = cols[1:]
 		for i, col := range cols {
 			cols[i] = strings.TrimSpace(col)
 			cols[i] = strings.TrimSuffix(cols[i], " *")
 		}
 
-		tableRows = append(tableRows, ObjectTableRow{
-			Field:       cols[1],
-			Type:        cols[2],
-			Description: cols[3],
-		})
+		rows = append(rows, cols)
 	}
+	return rows
+}
 
+// readSection returns the path to the section that contains pos and the
+// description of that section, which is everything between the section's
+// header and pos. If skipSiblings is true, then the sections before the
+// current one that aren't its parents are skipped, so that the path is
+// complete even if the section isn't the first one of its parent. Otherwise,
+// the path ends at the first of these sections.
+func readSection(src []byte, pos int, skipSiblings bool) ([]string, string, error) {
 	// Find the last # symbol up to pos. This is the current section.
 	headers := headerRe.FindAllStringSubmatchIndex(string(src[:pos]), -1)
 	if len(headers) == 0 {
-		return nil, fmt.Errorf("table at %d has no section", pos)
+		return nil, "", fmt.Errorf("table at %d has no section", pos)
 	}
 
 	var sections []string
@@ -157,6 +228,11 @@ func readTable(src []byte, pos int) (*ObjectTable, error) {
 	for i := len(headers) - 1; i >= 0; i-- {
 		hashes := bytes.Count(src[headers[i][2]:headers[i][3]], []byte{'#'})
 		if lastHashes > 0 && hashes >= lastHashes {
+			if skipSiblings {
+				// This section is a sibling of the current section or a
+				// subsection of one, so it's not a parent.
+				continue
+			}
 			// This section is a subsection of some other section that we don't
 			// care about.
 			break
@@ -170,13 +246,23 @@ func readTable(src []byte, pos int) (*ObjectTable, error) {
 	lastSectionRange := headers[len(headers)-1]
 	description := strings.TrimSpace(string(src[lastSectionRange[1]:pos]))
 
-	return &ObjectTable{
-		Sections:    sections, // TODO: implement
-		Description: description,
-		Table:       tableRows,
-		Source: Source{
-			Path:     "TODO",
-			Position: pos,
-		},
-	}, nil
+	return sections, description, nil
+}
+
+var anchorIllegalRe = regexp.MustCompile(`[^a-z0-9-]`)
+
+// SectionAnchor returns the anchor of the section with the given path as
+// used in links within the documentation, e.g. webhook-object-webhook-types.
+// The anchor is made of the last two sections.
+func SectionAnchor(sections []string) string {
+	if len(sections) > 2 {
+		sections = sections[len(sections)-2:]
+	}
+	parts := make([]string, len(sections))
+	for i, section := range sections {
+		part := strings.ToLower(section)
+		part = strings.ReplaceAll(part, " ", "-")
+		parts[i] = anchorIllegalRe.ReplaceAllString(part, "")
+	}
+	return strings.Join(parts, "-")
 }
@@ -1,6 +1,7 @@
 package docread
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 
@@ -116,9 +117,320 @@ func TestScrapeBytes(t *testing.T) {
 					Description: "the url used for executing the webhook (returned by the [webhooks](#DOCS_TOPICS_OAUTH2/webhooks) OAuth2 flow)",
 				},
 			},
-			Source: Source{Position: 236},
+			Source: Source{
+				Line:     14,
+				Anchor:   "webhook-object-webhook-structure",
+				Position: 236,
+			},
+		},
+	}, tables)
+}
+
+func TestScrapeEnumBytes(t *testing.T) {
+	tables, err := ScrapeEnumBytes([]byte(testSample))
+	assert.NoError(t, err)
+	assert.Equal(t, []EnumTable{
+		{
+			Sections: []string{"Webhook Resource", "Webhook Object", "Webhook Types"},
+			Values: []EnumTableRow{
+				{
+					Value:       "1",
+					Name:        "Incoming",
+					Description: "Incoming Webhooks can post messages to channels with a generated token",
+				},
+				{
+					Value:       "2",
+					Name:        "Channel Follower",
+					Description: "Channel Follower Webhooks are internal webhooks used with Channel Following to post new messages into channels",
+				},
+				{
+					Value:       "3",
+					Name:        "Application",
+					Description: "Application webhooks are webhooks used with Interactions",
+				},
+			},
+			Source: Source{
+				Line:     33,
+				Anchor:   "webhook-object-webhook-types",
+				Position: 3212,
+			},
 		},
 	}, tables)
+	assert.Equal(t, "webhook-object-webhook-types", tables[0].Anchor())
+}
+
+const testFlagSample = `
+### Message Object
+
+###### Message Flags
+
+| Flag            | Value   | Description                                                                                |
+| --------------- | ------- | ------------------------------------------------------------------------------------------ |
+| CROSSPOSTED     | 1 << 0  | this message has been published to subscribed channels (via Channel Following)             |
+| SUPPRESS_EMBEDS | 1 << 2  | do not include any embeds when serializing this message                                    |
+| LOADING         | 1 << 7  | this message is an Interaction Response and the bot is "thinking" (deprecated, do not use) |
+
+### User Object
+
+###### User Flags
+
+| Value   | Name    | Description            |
+| ------- | ------- | ---------------------- |
+| 1 << 0  | STAFF   | Discord Employee       |
+| 1 << 1  | PARTNER | Partnered Server Owner |
+`
+
+func TestScrapeFlagBytes(t *testing.T) {
+	tables, err := ScrapeFlagBytes([]byte(testFlagSample))
+	assert.NoError(t, err)
+	assert.Equal(t, []FlagTable{
+		{
+			Sections: []string{"Message Object", "Message Flags"},
+			Flags: []FlagTableRow{
+				{
+					Name:        "CROSSPOSTED",
+					Value:       1,
+					Expr:        "1 << 0",
+					Description: "this message has been published to subscribed channels (via Channel Following)",
+				},
+				{
+					Name:        "SUPPRESS_EMBEDS",
+					Value:       4,
+					Expr:        "1 << 2",
+					Description: "do not include any embeds when serializing this message",
+				},
+				{
+					Name:        "LOADING",
+					Value:       128,
+					Expr:        "1 << 7",
+					Description: "this message is an Interaction Response and the bot is \"thinking\" (deprecated, do not use)",
+					Deprecation: "deprecated, do not use",
+				},
+			},
+			Source: Source{
+				Line:     6,
+				Anchor:   "message-object-message-flags",
+				Position: 43,
+			},
+		},
+		{
+			Sections: []string{"User Object", "User Flags"},
+			Flags: []FlagTableRow{
+				{
+					Name:        "STAFF",
+					Value:       1,
+					Expr:        "1 << 0",
+					Description: "Discord Employee",
+				},
+				{
+					Name:        "PARTNER",
+					Value:       2,
+					Expr:        "1 << 1",
+					Description: "Partnered Server Owner",
+				},
+			},
+			Source: Source{
+				Line:     16,
+				Anchor:   "user-object-user-flags",
+				Position: 695,
+			},
+		},
+	}, tables)
+	assert.Equal(t, "message-object-message-flags", tables[0].Anchor())
+
+	enums, err := ScrapeEnumBytes([]byte(testFlagSample))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(enums))
+}
+
+func TestScrapeFlagBytesSkipsRows(t *testing.T) {
+	tables, err := ScrapeFlagBytes([]byte(`
+###### Message Flags
+
+| Flag            | Value  | Description           |
+| --------------- | ------ | --------------------- |
+| CROSSPOSTED     | 1 << 0 | published to channels |
+| IS_CROSSPOST    | 1 < 1  | from another channel  |
+| SUPPRESS_EMBEDS | 1 << 2 | no embeds             |
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(tables))
+
+	var names []string
+	for _, flag := range tables[0].Flags {
+		names = append(names, flag.Name)
+	}
+	assert.Equal(t, []string{"CROSSPOSTED", "SUPPRESS_EMBEDS"}, names)
+	assert.Equal(t, 1, len(tables[0].Skipped))
+	assert.Equal(t, `flag "IS_CROSSPOST": invalid value "1 < 1"`, tables[0].Skipped[0].Error())
+}
+
+const testGatewaySample = `
+## Send Events
+
+#### Heartbeat
+
+Used to maintain an active gateway connection.
+
+## Receive Events
+
+### Channels
+
+#### Channel Create
+
+Sent when a new guild channel is created. The inner payload is a [channel](#DOCS_RESOURCES_CHANNEL/channel-object) object.
+
+#### Channel Pins Update
+
+Sent when a message is pinned or unpinned in a text channel.
+
+###### Channel Pins Update Event Fields
+
+| Field      | Type      | Description       |
+| ---------- | --------- | ----------------- |
+| guild_id?  | snowflake | ID of the guild   |
+| channel_id | snowflake | ID of the channel |
+
+## Event Payloads
+`
+
+func TestScrapeGatewayEventsBytes(t *testing.T) {
+	events, err := ScrapeGatewayEventsBytes([]byte(testGatewaySample))
+	assert.NoError(t, err)
+	assert.Equal(t, []GatewayEvent{
+		{
+			Title:       "Channel Create",
+			Description: "Sent when a new guild channel is created. The inner payload is a [channel](#DOCS_RESOURCES_CHANNEL/channel-object) object.",
+			Payload:     "#DOCS_RESOURCES_CHANNEL/channel-object",
+			Source: Source{
+				Line:     12,
+				Anchor:   "channel-create",
+				Position: 114,
+			},
+		},
+		{
+			Title:       "Channel Pins Update",
+			Description: "Sent when a message is pinned or unpinned in a text channel.",
+			Fields: &ObjectTable{
+				Sections: []string{"Channel Pins Update", "Channel Pins Update Event Fields"},
+				Table: []ObjectTableRow{
+					{Field: "guild_id?", Type: "snowflake", Description: "ID of the guild"},
+					{Field: "channel_id", Type: "snowflake", Description: "ID of the channel"},
+				},
+				Source: Source{
+					Line:     22,
+					Anchor:   "channel-pins-update-channel-pins-update-event-fields",
+					Position: 388,
+				},
+			},
+			Source: Source{
+				Line:     16,
+				Anchor:   "channel-pins-update",
+				Position: 259,
+			},
+		},
+	}, events)
+	assert.Equal(t, "CHANNEL_PINS_UPDATE", events[1].Name())
+}
+
+const testErrorCodeSample = `
+## JSON
+
+Along with the HTTP error code, our API can also return more detailed error codes through a ` + "`code`" + ` key in the JSON error response.
+
+###### JSON Error Codes
+
+| Code  | Meaning                                                                |
+| ----- | ---------------------------------------------------------------------- |
+| 0     | General error (such as a malformed request body, amongst other things) |
+| 10001 | Unknown account                                                        |
+`
+
+func TestScrapeErrorCodesBytes(t *testing.T) {
+	table, err := ScrapeErrorCodesBytes([]byte(testErrorCodeSample))
+	assert.NoError(t, err)
+	assert.Equal(t, &ErrorCodeTable{
+		Sections: []string{"JSON", "JSON Error Codes"},
+		Codes: []ErrorCodeTableRow{
+			{Code: "0", Meaning: "General error (such as a malformed request body, amongst other things)"},
+			{Code: "10001", Meaning: "Unknown account"},
+		},
+		Source: Source{
+			Line:     8,
+			Anchor:   "json-json-error-codes",
+			Position: 167,
+		},
+	}, table)
+
+	_, err = ScrapeErrorCodesBytes([]byte(testSample))
+	assert.IsError(t, err, ErrNoErrorCodeTable)
+}
+
+const testPermissionSample = `
+## Permissions
+
+###### Bitwise Permission Flags
+
+| Permission            | Value                           | Description                                                       | Channel Type |
+| --------------------- | ------------------------------- | ----------------------------------------------------------------- | ------------ |
+| CREATE_INSTANT_INVITE | ` + "`0x0000000000000001` `(1 << 0)`" + ` | Allows creation of instant invites                                | T, V, S      |
+| ADMINISTRATOR \*       | ` + "`0x0000000000000008` `(1 << 3)`" + ` | Allows all permissions and bypasses channel permission overwrites |              |
+`
+
+func TestScrapePermissionsBytes(t *testing.T) {
+	table, err := ScrapePermissionsBytes([]byte(testPermissionSample))
+	assert.NoError(t, err)
+	assert.Equal(t, &FlagTable{
+		Sections: []string{"Permissions", "Bitwise Permission Flags"},
+		Flags: []FlagTableRow{
+			{
+				Name:        "CREATE_INSTANT_INVITE",
+				Value:       1,
+				Expr:        "1 << 0",
+				Description: "Allows creation of instant invites",
+			},
+			{
+				Name:        "ADMINISTRATOR",
+				Value:       8,
+				Expr:        "1 << 3",
+				Description: "Allows all permissions and bypasses channel permission overwrites",
+			},
+		},
+		Source: Source{
+			Line:     6,
+			Anchor:   "permissions-bitwise-permission-flags",
+			Position: 50,
+		},
+	}, table)
+
+	_, err = ScrapePermissionsBytes([]byte(testSample))
+	assert.IsError(t, err, ErrNoPermissionTable)
+}
+
+func TestEvalFlagValue(t *testing.T) {
+	tests := []struct {
+		expr string
+		want uint64
+	}{
+		{"0", 0},
+		{"8", 8},
+		{"0x10", 16},
+		{"1 << 0", 1},
+		{"1 << 40", 1 << 40},
+		{"(1 << 3)", 8},
+		{"1 << 0 | 1 << 2", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := EvalFlagValue(tt.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := EvalFlagValue("1 << x")
+	assert.Error(t, err)
 }
 
 func TestRelativeLevenshtein(t *testing.T) {
@@ -142,3 +454,126 @@ func TestRelativeLevenshtein(t *testing.T) {
 		})
 	}
 }
+
+func TestReadSection(t *testing.T) {
+	src := []byte(`# Resource
+
+### Object
+
+###### Object Structure
+
+| Field | Type | Description |
+
+###### Object Types
+
+| Value | Name | Description |
+`)
+	pos := bytes.LastIndex(src, []byte("| Value"))
+
+	sections, description, err := readSection(src, pos, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Object Types"}, sections)
+	assert.Equal(t, "", description)
+
+	sections, _, err = readSection(src, pos, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Resource", "Object", "Object Types"}, sections)
+}
+
+func TestFieldIndex(t *testing.T) {
+	tables := []ObjectTable{
+		{Table: []ObjectTableRow{{Field: "id"}, {Field: "name"}}},
+		{Table: []ObjectTableRow{{Field: "topic?"}, {Field: "name"}, {Field: "name"}}},
+		{Table: []ObjectTableRow{{Field: "code"}}},
+	}
+
+	index := NewFieldIndex(tables)
+	assert.Equal(t, FieldIndex{
+		"id":    {0},
+		"name":  {0, 1},
+		"topic": {1},
+		"code":  {2},
+	}, index)
+
+	assert.Equal(t, []int{0, 1}, index.Lookup([]FieldInfo{{Name: "topic"}, {Name: "name"}}))
+	assert.Equal(t, []int{2}, index.Lookup([]FieldInfo{{Name: "code"}, {Name: "unknown"}}))
+	assert.Equal(t, []int(nil), index.Lookup([]FieldInfo{{Name: "unknown"}}))
+}
+
+func TestParseTypeExpr(t *testing.T) {
+	tests := []struct {
+		typ  string
+		want string
+	}{
+		{"string", "string"},
+		{"?integer", "?integer"},
+		{"?snowflake", "?snowflake"},
+		{"ISO8601 timestamp", "timestamp"},
+		{"array of snowflakes", "array of snowflake"},
+		{"?array of strings", "?array of string"},
+		{"[user](#DOCS_RESOURCES_USER/user-object) object", "object(DOCS_RESOURCES_USER/user-object)"},
+		{"array of [user](#DOCS_RESOURCES_USER/user-object) objects", "array of object(DOCS_RESOURCES_USER/user-object)"},
+		{"partial [guild](#DOCS_RESOURCES_GUILD/guild-object) object", "partial object(DOCS_RESOURCES_GUILD/guild-object)"},
+		{"?[channel type](#DOCS_RESOURCES_CHANNEL/channel-object-channel-types)", "?reference(DOCS_RESOURCES_CHANNEL/channel-object-channel-types)"},
+		{"[snowflake](#DOCS_REFERENCE/snowflakes)", "snowflake"},
+		{"map of snowflakes to [user](#DOCS_RESOURCES_USER/user-object) objects", "map of snowflake to object(DOCS_RESOURCES_USER/user-object)"},
+		{"array of two integers (shard_id, num_shards)", "array of unknown(two integers (shard_id, num_shards))"},
+		{"integer or string", "unknown(integer or string)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			got := ParseTypeExpr(tt.typ)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestTypeSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"snowflake", "snowflake", 1},
+		{"snowflake", "string", 0.75},
+		{"?snowflake", "snowflake", 0.9},
+		{"integer", "boolean", 0},
+		{"array of snowflakes", "array of snowflakes", 1},
+		{"array of snowflakes", "snowflake", 0},
+		{"[user](#DOCS_RESOURCES_USER/user-object) object", "object", 1},
+		{"[user](#DOCS_RESOURCES_USER/user-object) object", "[member](#DOCS_RESOURCES_GUILD/guild-member-object) object", 0.75},
+		{"[type](#DOCS_RESOURCES_CHANNEL/channel-object-channel-types)", "integer", 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s-%s", tt.a, tt.b), func(t *testing.T) {
+			got := TypeSimilarity(ParseTypeExpr(tt.a), ParseTypeExpr(tt.b))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestObjectTableRowFieldInfo(t *testing.T) {
+	tests := []struct {
+		row      ObjectTableRow
+		name     string
+		typ      string
+		optional bool
+		nullable bool
+	}{
+		{ObjectTableRow{Field: "id", Type: "snowflake"}, "id", "snowflake", false, false},
+		{ObjectTableRow{Field: "topic?", Type: "string"}, "topic", "string", true, false},
+		{ObjectTableRow{Field: "icon", Type: "?string"}, "icon", "string", false, true},
+		{ObjectTableRow{Field: "avatar?", Type: "?string"}, "avatar", "string", true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.row.Field, func(t *testing.T) {
+			f := test.row.FieldInfo()
+			assert.Equal(t, test.name, f.Name)
+			assert.Equal(t, test.typ, f.Type)
+			assert.Equal(t, test.optional, f.Optional)
+			assert.Equal(t, test.nullable, f.Nullable)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package docread
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// EnumTable is a table of the values of an enum, such as
+//
+//	| Value | Name     | Description                  |
+//	| ----- | -------- | ---------------------------- |
+//	| 1     | Incoming | Incoming Webhooks can post … |
+type EnumTable struct {
+	Sections    []string /// path to the section
+	Description string
+	Values      []EnumTableRow
+
+	Source Source
+}
+
+// Anchor returns the anchor of the table's section that links within the
+// documentation use, e.g. webhook-object-webhook-types.
+func (t EnumTable) Anchor() string {
+	return t.Source.Anchor
+}
+
+// EnumTableRow is a row in an enum table.
+type EnumTableRow struct {
+	Value       string
+	Name        string
+	Description string
+}
+
+// Int parses the value of the row as an integer.
+func (r EnumTableRow) Int() (int64, error) {
+	return strconv.ParseInt(r.Value, 0, 64)
+}
+
+// ScrapeEnumFS scrapes the documentation filesystem for enum tables.
+func ScrapeEnumFS(dir fs.FS) ([]EnumTable, error) {
+	return scrapeFS(dir, scrapeEnumBytes)
+}
+
+// ScrapeEnumDir scrapes the given documentation directory for enum tables.
+func ScrapeEnumDir(path string) ([]EnumTable, error) {
+	return ScrapeEnumFS(os.DirFS(path))
+}
+
+// ScrapeEnumBytes scrapes the given documentation file's content for enum
+// tables.
+func ScrapeEnumBytes(b []byte) ([]EnumTable, error) {
+	return scrapeEnumBytes("", b)
+}
+
+var enumTableHeaderRe = regexp.MustCompile(`(?m)^\| (\w+) +\| (\w+) +\| Description +\|$`)
+
+// enumValueColumns and enumNameColumns are the headers of the columns of enum
+// tables that contain the values and the names.
+var (
+	enumValueColumns = []string{"Value", "ID", "Code"}
+	enumNameColumns  = []string{"Name", "Type", "Mode"}
+)
+
+func scrapeEnumBytes(path string, b []byte) ([]EnumTable, error) {
+	var tables []EnumTable
+	for _, match := range enumTableHeaderRe.FindAllSubmatchIndex(b, -1) {
+		first := string(b[match[2]:match[3]])
+		second := string(b[match[4]:match[5]])
+
+		var valueCol int
+		switch {
+		case slices.Contains(enumValueColumns, first) && slices.Contains(enumNameColumns, second):
+			valueCol = 0
+		case slices.Contains(enumNameColumns, first) && slices.Contains(enumValueColumns, second):
+			valueCol = 1
+		default:
+			continue
+		}
+
+		if isShiftTable(b, match[0], valueCol) {
+			// This is a flag table.
+			continue
+		}
+
+		t, err := readEnumTable(path, b, match[0], valueCol)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read enum table at %d: %w", match[0], err)
+		}
+		tables = append(tables, *t)
+	}
+	return tables, nil
+}
+
+func readEnumTable(path string, src []byte, pos, valueCol int) (*EnumTable, error) {
+	var values []EnumTableRow
+	for _, cols := range readRows(src, pos, 3) {
+		values = append(values, EnumTableRow{
+			Value:       strings.Trim(cols[valueCol], "`\""),
+			Name:        cols[1-valueCol],
+			Description: cols[2],
+		})
+	}
+
+	// Enum tables usually follow the structure table of their object, whose
+	// section has to be skipped to find their parents.
+	sections, description, err := readSection(src, pos, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EnumTable{
+		Sections:    sections,
+		Description: description,
+		Values:      values,
+		Source:      newSource(path, src, pos, sections),
+	}, nil
+}
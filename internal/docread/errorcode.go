@@ -0,0 +1,94 @@
+package docread
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// ErrorCodeTable is the table of the JSON error codes that the API returns,
+// such as
+//
+//	| Code  | Meaning             |
+//	| ----- | ------------------- |
+//	| 10001 | Unknown account     |
+//	| 10002 | Unknown application |
+type ErrorCodeTable struct {
+	Sections    []string /// path to the section
+	Description string
+	Codes       []ErrorCodeTableRow
+
+	Source Source
+}
+
+// ErrorCodeTableRow is a row in an error code table.
+type ErrorCodeTableRow struct {
+	Code    string
+	Meaning string
+}
+
+// Int parses the code of the row as an integer.
+func (r ErrorCodeTableRow) Int() (int64, error) {
+	return strconv.ParseInt(r.Code, 10, 64)
+}
+
+// ErrNoErrorCodeTable is returned if the documentation has no error code
+// table.
+var ErrNoErrorCodeTable = errors.New("no error code table")
+
+// ScrapeErrorCodesFS scrapes the documentation file with the given name within
+// the documentation filesystem for the error code table.
+func ScrapeErrorCodesFS(dir fs.FS, name string) (*ErrorCodeTable, error) {
+	b, err := fs.ReadFile(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return scrapeErrorCodesBytes(name, b)
+}
+
+// ScrapeErrorCodesFile scrapes the documentation file at the given path for
+// the error code table. The path of the source is relative to the file's
+// directory.
+func ScrapeErrorCodesFile(path string) (*ErrorCodeTable, error) {
+	return ScrapeErrorCodesFS(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+}
+
+// ScrapeErrorCodesBytes scrapes the given documentation file's content for the
+// error code table.
+func ScrapeErrorCodesBytes(b []byte) (*ErrorCodeTable, error) {
+	return scrapeErrorCodesBytes("", b)
+}
+
+var errorCodeTableHeaderRe = regexp.MustCompile(`(?m)^\| Code +\| Meaning +\|$`)
+
+func scrapeErrorCodesBytes(path string, b []byte) (*ErrorCodeTable, error) {
+	pos := errorCodeTableHeaderRe.FindIndex(b)
+	if pos == nil {
+		return nil, ErrNoErrorCodeTable
+	}
+
+	var rows []ErrorCodeTableRow
+	for _, cols := range readRows(b, pos[0], 2) {
+		row := ErrorCodeTableRow{Code: cols[0], Meaning: cols[1]}
+		if _, err := row.Int(); err != nil {
+			return nil, fmt.Errorf("invalid error code %q: %w", row.Code, err)
+		}
+		rows = append(rows, row)
+	}
+
+	sections, description, err := readSection(b, pos[0], true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ErrorCodeTable{
+		Sections:    sections,
+		Description: description,
+		Codes:       rows,
+		Source:      newSource(path, b, pos[0], sections),
+	}, nil
+}
@@ -1,6 +1,7 @@
 package docread
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/agnivade/levenshtein"
@@ -18,6 +19,7 @@ type FieldTable struct {
 type FieldInfo struct {
 	Name     string
 	Type     string
+	TypeExpr TypeExpr
 	Comment  string
 	Optional bool
 	Nullable bool
@@ -43,7 +45,10 @@ func CalculateFieldLikelihood(a, b FieldInfo) float64 {
 	if a.Name != b.Name {
 		return 0
 	}
-	return 0.5 + (relativeLevenshtein(a.Type, b.Type) / 2)
+	if a.TypeExpr.Kind == UnknownType || b.TypeExpr.Kind == UnknownType {
+		return 0.5 + (relativeLevenshtein(a.Type, b.Type) / 2)
+	}
+	return 0.5 + (TypeSimilarity(a.TypeExpr, b.TypeExpr) / 2)
 }
 
 // ObjectLikelihood is the likelihood that an object is the same as another
@@ -89,6 +94,46 @@ func CalculateObjectLikelihood(a, b FieldTable) ObjectLikelihood {
 	}
 }
 
+// FieldIndex is an inverted index from field names to the positions of the
+// tables that have them. It narrows down the tables that an object may be,
+// since tables without a field in common with an object are never likely to
+// be it.
+type FieldIndex map[string][]int
+
+// NewFieldIndex indexes the fields of the given tables.
+func NewFieldIndex(tables []ObjectTable) FieldIndex {
+	index := make(FieldIndex)
+	for i, table := range tables {
+		for _, row := range table.Table {
+			name := row.FieldInfo().Name
+			ixs := index[name]
+			// Tables may repeat a field, e.g. in rows with notes.
+			if len(ixs) > 0 && ixs[len(ixs)-1] == i {
+				continue
+			}
+			index[name] = append(ixs, i)
+		}
+	}
+	return index
+}
+
+// Lookup returns the positions of the tables that have at least one of the
+// given fields in ascending order.
+func (index FieldIndex) Lookup(fields []FieldInfo) []int {
+	seen := make(map[int]bool)
+	var ixs []int
+	for _, field := range fields {
+		for _, i := range index[field.Name] {
+			if !seen[i] {
+				seen[i] = true
+				ixs = append(ixs, i)
+			}
+		}
+	}
+	sort.Ints(ixs)
+	return ixs
+}
+
 func relativeLevenshtein(a, b string) float64 {
 	a = strings.ToLower(a)
 	b = strings.ToLower(b)
@@ -0,0 +1,194 @@
+package docread
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// FlagTable is a table of the bits of a bitfield, such as
+//
+//	| Flag        | Value  | Description                                 |
+//	| ----------- | ------ | ------------------------------------------- |
+//	| CROSSPOSTED | 1 << 0 | this message has been published to channels |
+type FlagTable struct {
+	Sections    []string /// path to the section
+	Description string
+	Flags       []FlagTableRow
+	// Skipped holds the errors of the rows that couldn't be read, such as
+	// rows whose values can't be evaluated. These rows aren't in Flags.
+	Skipped []error
+
+	Source Source
+}
+
+// Anchor returns the anchor of the table's section that links within the
+// documentation use, e.g. message-object-message-flags.
+func (t FlagTable) Anchor() string {
+	return t.Source.Anchor
+}
+
+// FlagTableRow is a row in a flag table.
+type FlagTableRow struct {
+	Name string
+	// Value is the evaluated value of the flag, e.g. 8 for 1 << 3.
+	Value uint64
+	// Expr is the value of the flag as written in the documentation.
+	Expr        string
+	Description string
+	// Deprecation is the deprecation note of the flag, or an empty string if
+	// the flag isn't deprecated.
+	Deprecation string
+}
+
+// ScrapeFlagFS scrapes the documentation filesystem for flag tables.
+func ScrapeFlagFS(dir fs.FS) ([]FlagTable, error) {
+	return scrapeFS(dir, scrapeFlagBytes)
+}
+
+// ScrapeFlagDir scrapes the given documentation directory for flag tables.
+func ScrapeFlagDir(path string) ([]FlagTable, error) {
+	return ScrapeFlagFS(os.DirFS(path))
+}
+
+// ScrapeFlagBytes scrapes the given documentation file's content for flag
+// tables.
+func ScrapeFlagBytes(b []byte) ([]FlagTable, error) {
+	return scrapeFlagBytes("", b)
+}
+
+// flagNameColumns are the headers of the columns of flag tables that contain
+// the names. Tables with any other name column are only flag tables if their
+// values are shifts.
+var flagNameColumns = []string{"Flag"}
+
+func scrapeFlagBytes(path string, b []byte) ([]FlagTable, error) {
+	var tables []FlagTable
+	for _, match := range enumTableHeaderRe.FindAllSubmatchIndex(b, -1) {
+		first := string(b[match[2]:match[3]])
+		second := string(b[match[4]:match[5]])
+
+		var valueCol int
+		switch {
+		case slices.Contains(flagNameColumns, first) && slices.Contains(enumValueColumns, second):
+			valueCol = 1
+		case slices.Contains(enumValueColumns, first) && slices.Contains(flagNameColumns, second):
+			valueCol = 0
+		case isShiftTable(b, match[0], 0) && slices.Contains(enumNameColumns, second):
+			valueCol = 0
+		case isShiftTable(b, match[0], 1) && slices.Contains(enumNameColumns, first):
+			valueCol = 1
+		default:
+			continue
+		}
+
+		t, err := readFlagTable(path, b, match[0], valueCol)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read flag table at %d: %w", match[0], err)
+		}
+		tables = append(tables, *t)
+	}
+	return tables, nil
+}
+
+// isShiftTable returns whether all values in the given column of the table at
+// pos are shifts, e.g. 1 << 3.
+func isShiftTable(src []byte, pos, col int) bool {
+	rows := readRows(src, pos, 3)
+	for _, cols := range rows {
+		if !strings.Contains(cols[col], "<<") {
+			return false
+		}
+	}
+	return len(rows) > 0
+}
+
+func readFlagTable(path string, src []byte, pos, valueCol int) (*FlagTable, error) {
+	var flags []FlagTableRow
+	var skipped []error
+	for _, cols := range readRows(src, pos, 3) {
+		expr := strings.Trim(cols[valueCol], "`")
+		value, err := EvalFlagValue(expr)
+		if err != nil {
+			// A single row, e.g. one with a typo, shouldn't cost the whole
+			// table.
+			skipped = append(skipped, fmt.Errorf("flag %q: %w", cols[1-valueCol], err))
+			continue
+		}
+
+		flags = append(flags, FlagTableRow{
+			Name:        strings.Trim(cols[1-valueCol], "`"),
+			Value:       value,
+			Expr:        expr,
+			Description: cols[2],
+			Deprecation: deprecationNote(cols[2]),
+		})
+	}
+
+	// Flag tables usually follow the structure table of their object, whose
+	// section has to be skipped to find their parents.
+	sections, description, err := readSection(src, pos, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FlagTable{
+		Sections:    sections,
+		Description: description,
+		Flags:       flags,
+		Skipped:     skipped,
+		Source:      newSource(path, src, pos, sections),
+	}, nil
+}
+
+var shiftRe = regexp.MustCompile(`^\(?\s*(\d+)\s*<<\s*(\d+)\s*\)?$`)
+
+// EvalFlagValue evaluates the value of a flag as written in the
+// documentation. Values may be integers, shifts such as 1 << 3 or both joined
+// by |.
+func EvalFlagValue(expr string) (uint64, error) {
+	var value uint64
+	for _, term := range strings.Split(expr, "|") {
+		term = strings.TrimSpace(term)
+
+		if m := shiftRe.FindStringSubmatch(term); m != nil {
+			x, err1 := strconv.ParseUint(m[1], 10, 64)
+			n, err2 := strconv.ParseUint(m[2], 10, 6)
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid shift %q", term)
+			}
+			value |= x << n
+			continue
+		}
+
+		x, err := strconv.ParseUint(term, 0, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %q", term)
+		}
+		value |= x
+	}
+	return value, nil
+}
+
+var (
+	deprecatedParenRe = regexp.MustCompile(`(?i)\(([^()]*\bdeprecated\b[^()]*)\)`)
+	deprecatedRe      = regexp.MustCompile(`(?i)\bdeprecated\b`)
+)
+
+// deprecationNote returns the deprecation note within the given description,
+// or an empty string if there is none. A parenthesized note is returned on its
+// own. Otherwise, the whole description is the note.
+func deprecationNote(description string) string {
+	if m := deprecatedParenRe.FindStringSubmatch(description); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	if deprecatedRe.MatchString(description) {
+		return description
+	}
+	return ""
+}
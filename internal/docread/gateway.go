@@ -0,0 +1,140 @@
+package docread
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GatewayEvent is an event that is received from the gateway, as documented
+// in the Receive Events section of the Gateway Events documentation, such as
+//
+//	#### Channel Pins Update
+//
+//	Sent when a message is pinned or unpinned in a text channel.
+//
+//	###### Channel Pins Update Event Fields
+//
+//	| Field      | Type      | Description       |
+//	| ---------- | --------- | ----------------- |
+//	| channel_id | snowflake | ID of the channel |
+type GatewayEvent struct {
+	Title       string // title of the event's section, e.g. Message Create
+	Description string
+	// Payload is the link to the object that is the inner payload of the
+	// event, e.g. #DOCS_RESOURCES_CHANNEL/message-object. It is empty if the
+	// event's payload isn't an object documented elsewhere.
+	Payload string
+	// Fields is the table of the fields of the event, if any. If the event
+	// has a Payload, then these are extra fields of that object.
+	Fields *ObjectTable
+
+	Source Source
+}
+
+// Name returns the name of the event that the gateway dispatches it with,
+// e.g. MESSAGE_CREATE.
+func (e GatewayEvent) Name() string {
+	return strings.ToUpper(strings.Join(strings.Fields(e.Title), "_"))
+}
+
+// ScrapeGatewayEventsFS scrapes the Gateway Events documentation file with the
+// given name within the documentation filesystem.
+func ScrapeGatewayEventsFS(dir fs.FS, name string) ([]GatewayEvent, error) {
+	b, err := fs.ReadFile(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return scrapeGatewayEventsBytes(name, b)
+}
+
+// ScrapeGatewayEventsFile scrapes the Gateway Events documentation file at the
+// given path. The paths of the sources are relative to the file's directory.
+func ScrapeGatewayEventsFile(path string) ([]GatewayEvent, error) {
+	return ScrapeGatewayEventsFS(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+}
+
+// ScrapeGatewayEventsBytes scrapes the given Gateway Events documentation
+// file's content.
+func ScrapeGatewayEventsBytes(b []byte) ([]GatewayEvent, error) {
+	return scrapeGatewayEventsBytes("", b)
+}
+
+// receiveEventsSection is the section that documents the events received from
+// the gateway.
+const receiveEventsSection = "Receive Events"
+
+// eventHeaderLevel is the header level of each event's section.
+const eventHeaderLevel = 4
+
+var payloadLinkRe = regexp.MustCompile(
+	`inner payload is an? (?:partial )?\[[^\]]+\]\((#DOCS_[A-Z0-9_]+/[a-z0-9-]+)\)`)
+
+func scrapeGatewayEventsBytes(path string, b []byte) ([]GatewayEvent, error) {
+	headers := headerRe.FindAllSubmatchIndex(b, -1)
+	level := func(i int) int {
+		return bytes.Count(b[headers[i][2]:headers[i][3]], []byte{'#'})
+	}
+	title := func(i int) string {
+		return string(b[headers[i][4]:headers[i][5]])
+	}
+
+	start := -1
+	for i := range headers {
+		if level(i) == 2 && title(i) == receiveEventsSection {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return nil, fmt.Errorf("no %q section", receiveEventsSection)
+	}
+
+	var events []GatewayEvent
+	for i := start + 1; i < len(headers) && level(i) > 2; i++ {
+		if level(i) != eventHeaderLevel {
+			continue
+		}
+
+		// The event's section ends at the next section that isn't one of
+		// its subsections.
+		end := len(b)
+		for j := i + 1; j < len(headers); j++ {
+			if level(j) <= eventHeaderLevel {
+				end = headers[j][0]
+				break
+			}
+		}
+
+		// The description ends at the first subsection.
+		descEnd := end
+		if i+1 < len(headers) && headers[i+1][0] < end {
+			descEnd = headers[i+1][0]
+		}
+
+		event := GatewayEvent{
+			Title:       title(i),
+			Description: strings.TrimSpace(string(b[headers[i][1]:descEnd])),
+			Source:      newSource(path, b, headers[i][0], []string{title(i)}),
+		}
+		if m := payloadLinkRe.FindStringSubmatch(event.Description); m != nil {
+			event.Payload = m[1]
+		}
+
+		if pos := tableHeaderRe.FindIndex(b[headers[i][1]:end]); pos != nil {
+			t, err := readTable(path, b, headers[i][1]+pos[0])
+			if err != nil {
+				return nil, fmt.Errorf("cannot read table of event %q: %w", event.Title, err)
+			}
+			event.Fields = t
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
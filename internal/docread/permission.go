@@ -0,0 +1,98 @@
+package docread
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrNoPermissionTable is returned if the documentation has no permission
+// table.
+var ErrNoPermissionTable = errors.New("no permission table")
+
+// ScrapePermissionsFS scrapes the documentation file with the given name within
+// the documentation filesystem for the table of the permission bits.
+func ScrapePermissionsFS(dir fs.FS, name string) (*FlagTable, error) {
+	b, err := fs.ReadFile(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return scrapePermissionsBytes(name, b)
+}
+
+// ScrapePermissionsFile scrapes the documentation file at the given path for
+// the table of the permission bits. The path of the source is relative to the
+// file's directory.
+func ScrapePermissionsFile(path string) (*FlagTable, error) {
+	return ScrapePermissionsFS(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+}
+
+// ScrapePermissionsBytes scrapes the given documentation file's content for
+// the table of the permission bits, such as
+//
+//	| Permission            | Value                           | Description                        | Channel Type |
+//	| --------------------- | ------------------------------- | ---------------------------------- | ------------ |
+//	| CREATE_INSTANT_INVITE | `0x0000000000000001` `(1 << 0)` | Allows creation of instant invites | T, V, S      |
+//	| KICK_MEMBERS \*       | `0x0000000000000002` `(1 << 1)` | Allows kicking members             |              |
+func ScrapePermissionsBytes(b []byte) (*FlagTable, error) {
+	return scrapePermissionsBytes("", b)
+}
+
+var permissionTableHeaderRe = regexp.MustCompile(
+	`(?m)^\| Permission +\| Value +\| Description +\| Channel Type +\|$`)
+
+func scrapePermissionsBytes(path string, b []byte) (*FlagTable, error) {
+	pos := permissionTableHeaderRe.FindIndex(b)
+	if pos == nil {
+		return nil, ErrNoPermissionTable
+	}
+
+	var flags []FlagTableRow
+	var skipped []error
+	for _, cols := range readRows(b, pos[0], 4) {
+		// Permissions that require two-factor authentication are marked
+		// with an asterisk.
+		name := strings.TrimSpace(strings.TrimSuffix(cols[0], `\*`))
+
+		// Values are written as both a hexadecimal number and a shift.
+		values := strings.Fields(strings.ReplaceAll(cols[1], "`", ""))
+		if len(values) == 0 {
+			skipped = append(skipped, fmt.Errorf("permission %q has no value", name))
+			continue
+		}
+		value, err := EvalFlagValue(values[0])
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("permission %q: %w", name, err))
+			continue
+		}
+		expr := values[0]
+		if len(values) > 1 {
+			expr = strings.Trim(strings.Join(values[1:], " "), "()")
+		}
+
+		flags = append(flags, FlagTableRow{
+			Name:        name,
+			Value:       value,
+			Expr:        expr,
+			Description: cols[2],
+			Deprecation: deprecationNote(cols[2]),
+		})
+	}
+
+	sections, description, err := readSection(b, pos[0], true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FlagTable{
+		Sections:    sections,
+		Description: description,
+		Flags:       flags,
+		Skipped:     skipped,
+		Source:      newSource(path, b, pos[0], sections),
+	}, nil
+}
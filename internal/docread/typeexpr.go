@@ -0,0 +1,269 @@
+package docread
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TypeKind is the kind of a type expression.
+type TypeKind int
+
+const (
+	// UnknownType is a type that couldn't be parsed, e.g. "integer or
+	// string". Its text is kept in Raw.
+	UnknownType TypeKind = iota
+	// PrimitiveType is a primitive type, e.g. snowflake.
+	PrimitiveType
+	// ObjectType is an object, e.g. "[user](#DOCS_RESOURCES_USER/user-object)
+	// object". Objects may not have a reference.
+	ObjectType
+	// ReferenceType is a link to another section that isn't an object, such
+	// as an enum.
+	ReferenceType
+	// ArrayType is an array of Elem, e.g. "array of snowflakes".
+	ArrayType
+	// MapType is a map of Key to Elem, e.g. "map of snowflakes to
+	// [user](#DOCS_RESOURCES_USER/user-object) objects".
+	MapType
+)
+
+// Primitive is a primitive type.
+type Primitive string
+
+const (
+	PrimitiveString    Primitive = "string"
+	PrimitiveInteger   Primitive = "integer"
+	PrimitiveFloat     Primitive = "float"
+	PrimitiveBoolean   Primitive = "boolean"
+	PrimitiveSnowflake Primitive = "snowflake"
+	PrimitiveTimestamp Primitive = "timestamp"
+)
+
+// primitiveNames maps the names of primitive types as written in the
+// documentation to the primitives.
+var primitiveNames = map[string]Primitive{
+	"string":            PrimitiveString,
+	"integer":           PrimitiveInteger,
+	"int":               PrimitiveInteger,
+	"float":             PrimitiveFloat,
+	"double":            PrimitiveFloat,
+	"number":            PrimitiveFloat,
+	"boolean":           PrimitiveBoolean,
+	"bool":              PrimitiveBoolean,
+	"snowflake":         PrimitiveSnowflake,
+	"timestamp":         PrimitiveTimestamp,
+	"iso8601 timestamp": PrimitiveTimestamp,
+}
+
+// TypeRef is a link to the section that documents a type.
+type TypeRef struct {
+	Title  string // e.g. user
+	Page   string // e.g. DOCS_RESOURCES_USER
+	Anchor string // e.g. user-object
+}
+
+// TypeExpr is a type expression parsed from the type column of an object
+// table, such as "?array of [user](#DOCS_RESOURCES_USER/user-object) objects".
+type TypeExpr struct {
+	Kind     TypeKind
+	Nullable bool
+
+	// Primitive is the primitive of a PrimitiveType.
+	Primitive Primitive
+	// Ref is the link of an ObjectType or a ReferenceType. It is zero for
+	// objects without a link.
+	Ref TypeRef
+	// Partial is whether an ObjectType is a partial object.
+	Partial bool
+	// Key is the key type of a MapType.
+	Key *TypeExpr
+	// Elem is the element type of an ArrayType or the value type of a
+	// MapType.
+	Elem *TypeExpr
+
+	// Raw is the text that the expression was parsed from.
+	Raw string
+}
+
+var (
+	typeLinkRe          = regexp.MustCompile(`^\[([^\]]+)\]\(([^()\s]+)\)(?: (objects?))?$`)
+	typeParentheticalRe = regexp.MustCompile(`\s+\([^()]*\)$`)
+	mapOfRe             = regexp.MustCompile(`^map of (.+?) to (.+)$`)
+)
+
+// ParseTypeExpr parses the given type as written in the type column of an
+// object table. Types that can't be parsed are UnknownType.
+func ParseTypeExpr(typ string) TypeExpr {
+	raw := strings.TrimSpace(typ)
+	expr := parseTypeExpr(raw)
+	expr.Raw = raw
+	return expr
+}
+
+func parseTypeExpr(typ string) TypeExpr {
+	typ = strings.TrimSpace(typ)
+
+	if rest, ok := strings.CutPrefix(typ, "?"); ok {
+		expr := parseTypeExpr(rest)
+		expr.Nullable = true
+		return expr
+	}
+
+	for _, prefix := range []string{"array of ", "list of "} {
+		if rest, ok := strings.CutPrefix(typ, prefix); ok {
+			elem := ParseTypeExpr(rest)
+			return TypeExpr{Kind: ArrayType, Elem: &elem}
+		}
+	}
+
+	if m := mapOfRe.FindStringSubmatch(typ); m != nil {
+		key := ParseTypeExpr(m[1])
+		elem := ParseTypeExpr(m[2])
+		return TypeExpr{Kind: MapType, Key: &key, Elem: &elem}
+	}
+
+	if rest, ok := strings.CutPrefix(typ, "partial "); ok {
+		expr := parseTypeExpr(rest)
+		if expr.Kind == ObjectType || expr.Kind == ReferenceType {
+			expr.Kind = ObjectType
+			expr.Partial = true
+		}
+		return expr
+	}
+
+	// Notes such as "(shard_id, num_shards)" don't change the type.
+	typ = typeParentheticalRe.ReplaceAllString(typ, "")
+
+	if m := typeLinkRe.FindStringSubmatch(typ); m != nil {
+		// Some primitives link to their documentation, e.g.
+		// [snowflake](#DOCS_REFERENCE/snowflakes).
+		if p, ok := parsePrimitive(m[1]); ok {
+			return TypeExpr{Kind: PrimitiveType, Primitive: p}
+		}
+
+		ref := TypeRef{Title: m[1]}
+		ref.Page, ref.Anchor, _ = strings.Cut(strings.TrimPrefix(m[2], "#"), "/")
+
+		kind := ReferenceType
+		if m[3] != "" {
+			kind = ObjectType
+		}
+		return TypeExpr{Kind: kind, Ref: ref}
+	}
+
+	if typ == "object" || typ == "objects" {
+		return TypeExpr{Kind: ObjectType}
+	}
+
+	if p, ok := parsePrimitive(typ); ok {
+		return TypeExpr{Kind: PrimitiveType, Primitive: p}
+	}
+
+	return TypeExpr{Kind: UnknownType}
+}
+
+// parsePrimitive parses the given name of a primitive type. Plural names such
+// as snowflakes are also accepted.
+func parsePrimitive(name string) (Primitive, bool) {
+	name = strings.ToLower(name)
+	for _, name := range []string{name, strings.TrimSuffix(name, "s")} {
+		if p, ok := primitiveNames[name]; ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// String formats the type expression in a canonical form, e.g. "?array of
+// partial object(DOCS_RESOURCES_GUILD/guild-object)".
+func (t TypeExpr) String() string {
+	var b strings.Builder
+	if t.Nullable {
+		b.WriteByte('?')
+	}
+
+	switch t.Kind {
+	case PrimitiveType:
+		b.WriteString(string(t.Primitive))
+	case ObjectType, ReferenceType:
+		if t.Partial {
+			b.WriteString("partial ")
+		}
+		if t.Kind == ObjectType {
+			b.WriteString("object")
+		} else {
+			b.WriteString("reference")
+		}
+		if t.Ref != (TypeRef{}) {
+			b.WriteString("(" + t.Ref.Page + "/" + t.Ref.Anchor + ")")
+		}
+	case ArrayType:
+		b.WriteString("array of " + t.Elem.String())
+	case MapType:
+		b.WriteString("map of " + t.Key.String() + " to " + t.Elem.String())
+	default:
+		b.WriteString("unknown(" + t.Raw + ")")
+	}
+
+	return b.String()
+}
+
+// TypeSimilarity returns how similar the given type expressions are, from 0
+// to 1. Types that are compatible but not the same, such as a snowflake and a
+// string, are partially similar. Unknown types are compared by their text.
+func TypeSimilarity(a, b TypeExpr) float64 {
+	similarity := typeSimilarity(a, b)
+	if a.Nullable != b.Nullable {
+		similarity *= 0.9
+	}
+	return similarity
+}
+
+func typeSimilarity(a, b TypeExpr) float64 {
+	if a.Kind == UnknownType || b.Kind == UnknownType {
+		return relativeLevenshtein(a.Raw, b.Raw)
+	}
+
+	if a.Kind != b.Kind {
+		// References are usually enums, which are primitives.
+		if (a.Kind == ReferenceType && b.Kind == PrimitiveType) ||
+			(a.Kind == PrimitiveType && b.Kind == ReferenceType) {
+			return 0.5
+		}
+		return 0
+	}
+
+	switch a.Kind {
+	case PrimitiveType:
+		return primitiveSimilarity(a.Primitive, b.Primitive)
+	case ObjectType, ReferenceType:
+		if a.Ref == (TypeRef{}) || b.Ref == (TypeRef{}) || a.Ref.Anchor == b.Ref.Anchor {
+			return 1
+		}
+		return 0.75
+	case ArrayType:
+		return 0.5 + TypeSimilarity(*a.Elem, *b.Elem)/2
+	case MapType:
+		return 0.5 + (TypeSimilarity(*a.Key, *b.Key)+TypeSimilarity(*a.Elem, *b.Elem))/4
+	}
+	return 0
+}
+
+// compatiblePrimitives are the pairs of primitives that are encoded the same
+// way, so one may be documented as the other.
+var compatiblePrimitives = map[[2]Primitive]bool{
+	{PrimitiveString, PrimitiveSnowflake}: true,
+	{PrimitiveString, PrimitiveTimestamp}: true,
+	{PrimitiveInteger, PrimitiveFloat}:    true,
+}
+
+func primitiveSimilarity(a, b Primitive) float64 {
+	switch {
+	case a == b:
+		return 1
+	case compatiblePrimitives[[2]Primitive{a, b}], compatiblePrimitives[[2]Primitive{b, a}]:
+		return 0.75
+	default:
+		return 0
+	}
+}
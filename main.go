@@ -25,6 +25,14 @@ var (
 	documentationDir    = filepath.Join(os.Getenv("DISCORD_API_DOCS"), "docs", "resources")
 	initialsFile        string
 	snowflakeFieldsFile string
+	docSectionsFile     string
+	gatewayDocsFile     = filepath.Join(os.Getenv("DISCORD_API_DOCS"), "docs", "topics", "Gateway_Events.md")
+	errorCodesDocsFile  string
+	permissionsDocsFile string
+	withDocs            bool
+	withGateway         bool
+	discrepancyReport   string
+	docsThreshold       = 6.5
 	numWorkers          = runtime.GOMAXPROCS(-1)
 )
 
@@ -36,8 +44,16 @@ func init() {
 	flag.StringVar(&optionPkg, "option-pkg", optionPkg, "option package")
 	flag.StringVar(&openapiFile, "openapi", openapiFile, "openapi file")
 	flag.StringVar(&documentationDir, "docs", documentationDir, "documentation directory")
+	flag.BoolVar(&withDocs, "with-docs", withDocs, "document generated fields using the tables in -docs")
+	flag.StringVar(&gatewayDocsFile, "gateway-docs", gatewayDocsFile, "gateway events documentation file")
+	flag.StringVar(&errorCodesDocsFile, "error-codes-docs", errorCodesDocsFile, "JSON error codes documentation file used with -with-docs to generate error codes, e.g. docs/topics/Opcodes_and_Status_Codes.md")
+	flag.StringVar(&permissionsDocsFile, "permissions-docs", permissionsDocsFile, "permissions documentation file used with -with-docs to generate the permission constants, e.g. docs/topics/Permissions.md")
+	flag.BoolVar(&withGateway, "with-gateway", withGateway, "generate gateway events using -gateway-docs, requires -with-docs")
+	flag.StringVar(&discrepancyReport, "discrepancy-report", discrepancyReport, "write a report of the discrepancies between the spec and -docs in the given format (markdown or json) instead of code, requires -with-docs")
+	flag.Float64Var(&docsThreshold, "docs-threshold", docsThreshold, "minimum likelihood score of a documentation table")
 	flag.StringVar(&initialsFile, "initials", initialsFile, "initials file")
 	flag.StringVar(&snowflakeFieldsFile, "snowflake-fields", snowflakeFieldsFile, "snowflake fields file")
+	flag.StringVar(&docSectionsFile, "doc-sections", docSectionsFile, "file pinning schemas to the sections of their documentation tables")
 	flag.IntVar(&numWorkers, "workers", numWorkers, "number of workers")
 }
 
@@ -60,9 +76,32 @@ func main() {
 		addSnowflakeFieldsFile(string(b))
 	}
 
-	// if err := scrapeDocs(); err != nil {
-	// 	log.Fatalln(err)
-	// }
+	if docSectionsFile != "" {
+		b, err := os.ReadFile(docSectionsFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := addDocSectionsFile(string(b)); err != nil {
+			log.Fatalln(docSectionsFile+":", err)
+		}
+	}
+
+	if withDocs {
+		if err := scrapeDocs(); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	if withGateway {
+		// Gateway events link to the documented objects, which are only known
+		// with -with-docs.
+		if !withDocs {
+			log.Fatalln("-with-gateway requires -with-docs")
+		}
+		if err := scrapeGatewayDocs(); err != nil {
+			log.Fatalln(err)
+		}
+	}
 
 	openapiJSON, err := os.ReadFile(openapiFile)
 	if err != nil {
@@ -74,6 +113,20 @@ func main() {
 		log.Fatalln(err)
 	}
 
+	if discrepancyReport != "" {
+		if !withDocs {
+			log.Fatalln("-discrepancy-report requires -with-docs")
+		}
+
+		report, err := ReportDiscrepancies(doc, discrepancyReport)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		writeOutput(report)
+		return
+	}
+
 	code, err := Generate(doc, outputPkg)
 	if err != nil {
 		log.Fatalln(err)
@@ -85,18 +138,21 @@ func main() {
 		log.Println("cannot format code:", err)
 	}
 
-	var out io.WriteCloser
-	if outputFile == "-" {
-		out = os.Stdout
-	} else {
-		out, err := os.Create(outputFile)
+	writeOutput(code)
+}
+
+// writeOutput writes b to the output file, or stdout if the output file is -.
+func writeOutput(b []byte) {
+	var out io.WriteCloser = os.Stdout
+	if outputFile != "-" {
+		f, err := os.Create(outputFile)
 		if err != nil {
 			log.Fatalln(err)
 		}
-		defer out.Close()
+		out = f
 	}
 
-	if _, err := out.Write(code); err != nil {
+	if _, err := out.Write(b); err != nil {
 		log.Fatalln(err)
 	}
 
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/diamondburned/gotk4/gir/girgen/strcases"
+	"github.com/hashicorp/go-hclog"
+	"golang.org/x/exp/slices"
+	"libdb.so/arikawa-generator/internal/cmt"
+
+	openapibase "github.com/pb33f/libopenapi/datamodel/high/base"
+	openapi "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+const clientCode = `// Requester sends requests to the Discord API.
+type Requester interface {
+	// Request sends a request with the given method to the given path. query
+	// may be nil. If body is not nil, then it must be encoded as JSON. If resp
+	// is not nil, then the response body must be decoded as JSON into it.
+	Request(ctx context.Context, method, path string, query url.Values, body, resp any) error
+}
+
+// Client is a Discord REST API client. Each operation in the API is a method
+// on Client.
+type Client struct {
+	Requester
+}
+
+// NewClient creates a new Client that sends its requests using r.
+func NewClient(r Requester) *Client {
+	return &Client{Requester: r}
+}
+
+`
+
+// operationMethods is the list of HTTP methods that operations can have, in
+// the order that they are generated.
+var operationMethods = []string{
+	"get", "put", "post", "delete", "options", "head", "patch", "trace",
+}
+
+// generateClient generates the Client type and a method on it for each
+// operation within the given paths.
+func generateClient(state *generateState, paths *openapi.Paths) {
+	if paths == nil || len(paths.PathItems) == 0 {
+		return
+	}
+
+	state.addGenerated("Client", clientCode)
+
+	pathsIter := orderedMap(paths.PathItems)
+	pathsIter(func(path string, item *openapi.PathItem) bool {
+		operations := item.GetOperations()
+		for _, method := range operationMethods {
+			op, ok := operations[method]
+			if !ok {
+				continue
+			}
+
+			g := &generator{state: state}
+			name := operationName(method, path, op)
+			content := g.captured(func(g *generator) {
+				g.generateOperation(name, method, path, item, op)
+			})
+			state.addGenerated("Client."+name, content)
+		}
+		return true
+	})
+}
+
+type operationParam struct {
+	Name   string // Go name
+	GoType string
+	*openapi.Parameter
+}
+
+func (g *generator) generateOperation(name, method, path string, item *openapi.PathItem, op *openapi.Operation) {
+	log := hclog.FromContext(g.state.ctx)
+	log.Debug("generating operation", "name", name, "method", method, "path", path)
+
+	var pathParams, queryParams []operationParam
+	for _, param := range mergeParameters(item.Parameters, op.Parameters) {
+		if param.Schema == nil {
+			g.error(fmt.Errorf("operation %s has parameter %q without schema", name, param.Name))
+			continue
+		}
+
+		p := operationParam{Parameter: param}
+		p.GoType = g.operationType(name, param.Name, param.Schema)
+
+		switch param.In {
+		case "path":
+			p.Name = snakeToUnexportedGo(param.Name)
+			pathParams = append(pathParams, p)
+		case "query":
+			p.Name = snakeToGo(param.Name)
+			queryParams = append(queryParams, p)
+		default:
+			log.Debug("skipping unsupported parameter",
+				"operation", name,
+				"param", param.Name,
+				"in", param.In)
+		}
+	}
+
+	var bodyType string
+	if op.RequestBody != nil {
+		if media, ok := op.RequestBody.Content["application/json"]; ok && media.Schema != nil {
+			bodyType = g.operationType(name, "Request", media.Schema)
+			// Only named types can have a Validate method, and unions are
+			// interfaces, which can't.
+			path := schemaPath{{Name: bodyType, SchemaProxy: media.Schema}}
+			if token.IsIdentifier(bodyType) && !g.proxyIsUnion(path, media.Schema) {
+				g.state.addRequestType(bodyType)
+			}
+		} else {
+			log.Warn("operation has no JSON request body, skipping it",
+				"operation", name)
+		}
+	}
+
+	var respType string
+	if proxy := operationResponse(op); proxy != nil {
+		respType = g.operationType(name, "Response", proxy)
+	}
+
+	var queryType string
+	if len(queryParams) > 0 {
+		queryType = name + "Query"
+		g.state.addGenerated(queryType, g.captured(func(g *generator) {
+			g.generateOperationQuery(queryType, queryParams)
+		}))
+	}
+
+	// Write the method's documentation.
+	doc := op.Description
+	if doc == "" {
+		doc = op.Summary
+	}
+	if doc != "" {
+		fmt.Fprint(g.output, g.docComment(name, doc, cmt.Opts{}))
+		fmt.Fprintln(g.output, "//")
+	}
+	fmt.Fprintf(g.output, "// %s performs %s %s.\n",
+		name, strings.ToUpper(method), path)
+	if op.Deprecated != nil && *op.Deprecated {
+		fmt.Fprintln(g.output, "//")
+		fmt.Fprintln(g.output, "// Deprecated: this operation is deprecated.")
+	}
+
+	// Write the method's signature.
+	fmt.Fprintf(g.output, "func (c *Client) %s(ctx context.Context", name)
+	for _, param := range pathParams {
+		fmt.Fprintf(g.output, ", %s %s", param.Name, param.GoType)
+	}
+	if queryType != "" {
+		fmt.Fprintf(g.output, ", query %s", queryType)
+	}
+	if bodyType != "" {
+		fmt.Fprintf(g.output, ", body %s", bodyType)
+	}
+	fmt.Fprint(g.output, ") ")
+	if respType != "" {
+		fmt.Fprintf(g.output, "(%s, error)", respType)
+	} else {
+		fmt.Fprint(g.output, "error")
+	}
+	fmt.Fprintln(g.output, " {")
+
+	// Write the method's body.
+	queryArg := "nil"
+	if queryType != "" {
+		queryArg = "query.Values()"
+	}
+	bodyArg := "nil"
+	if bodyType != "" {
+		bodyArg = "body"
+	}
+	pathArg := g.operationPath(name, path, pathParams)
+
+	if respType != "" {
+		fmt.Fprintf(g.output, "\tvar resp %s\n", respType)
+		fmt.Fprintf(g.output, "\terr := c.Request(ctx, %q, %s, %s, %s, &resp)\n",
+			strings.ToUpper(method), pathArg, queryArg, bodyArg)
+		fmt.Fprintln(g.output, "\treturn resp, err")
+	} else {
+		fmt.Fprintf(g.output, "\treturn c.Request(ctx, %q, %s, %s, %s, nil)\n",
+			strings.ToUpper(method), pathArg, queryArg, bodyArg)
+	}
+	fmt.Fprint(g.output, "}\n\n")
+}
+
+var pathParamRe = regexp.MustCompile(`\{([^}]+)\}`)
+
+// operationPath returns a Go expression that builds the given path using the
+// given path parameters.
+func (g *generator) operationPath(name, path string, params []operationParam) string {
+	var parts []string
+	var last int
+	for _, match := range pathParamRe.FindAllStringSubmatchIndex(path, -1) {
+		if match[0] > last {
+			parts = append(parts, fmt.Sprintf("%q", path[last:match[0]]))
+		}
+		last = match[1]
+
+		paramName := path[match[2]:match[3]]
+		paramIx := slices.IndexFunc(params, func(p operationParam) bool {
+			return p.Parameter.Name == paramName
+		})
+		if paramIx == -1 {
+			g.error(fmt.Errorf("operation %s has undeclared path parameter %q", name, paramName))
+			continue
+		}
+
+		parts = append(parts, fmt.Sprintf("url.PathEscape(fmt.Sprint(%s))", params[paramIx].Name))
+	}
+	if last < len(path) {
+		parts = append(parts, fmt.Sprintf("%q", path[last:]))
+	}
+	return strings.Join(parts, " + ")
+}
+
+func (g *generator) generateOperationQuery(name string, params []operationParam) {
+	fmt.Fprint(g.output, cmt.Prettify(name,
+		"contains the query parameters of "+strings.TrimSuffix(name, "Query"),
+		cmt.Opts{}))
+	fmt.Fprintf(g.output, "type %s struct {\n", name)
+	for _, param := range params {
+		if param.Description != "" {
+			fmt.Fprint(g.output, g.docComment(param.Name, param.Description, cmt.Opts{
+				OriginalName: param.Parameter.Name,
+				Indent:       1,
+			}))
+		}
+		goType := param.GoType
+		if !param.Required {
+			goType = "option.Optional[" + strings.TrimPrefix(goType, "*") + "]"
+		}
+		fmt.Fprintf(g.output, "\t%s %s\n", param.Name, goType)
+	}
+	fmt.Fprint(g.output, "}\n\n")
+
+	fmt.Fprintln(g.output, "// Values encodes q into URL query values.")
+	fmt.Fprintf(g.output, "func (q %s) Values() url.Values {\n", name)
+	fmt.Fprintln(g.output, "\tv := make(url.Values)")
+	for _, param := range params {
+		value := "q." + param.Name
+		if !param.Required {
+			fmt.Fprintf(g.output, "\tif x, ok := %s.Get(); ok {\n", value)
+			value = "x"
+		}
+		if strings.HasPrefix(strings.TrimPrefix(param.GoType, "*"), "[]") {
+			fmt.Fprintf(g.output, "\tfor _, item := range %s {\n", value)
+			fmt.Fprintf(g.output, "\t\tv.Add(%q, fmt.Sprint(item))\n", param.Parameter.Name)
+			fmt.Fprintln(g.output, "\t}")
+		} else {
+			fmt.Fprintf(g.output, "\tv.Set(%q, fmt.Sprint(%s))\n", param.Parameter.Name, value)
+		}
+		if !param.Required {
+			fmt.Fprintln(g.output, "\t}")
+		}
+	}
+	fmt.Fprintln(g.output, "\treturn v")
+	fmt.Fprint(g.output, "}\n\n")
+}
+
+// operationType returns the Go type of the given schema used within an
+// operation. Schemas that need a name are generated globally as the operation
+// name followed by the given suffix.
+func (g *generator) operationType(operation, suffix string, proxy *openapibase.SchemaProxy) string {
+	if proxyIsGeneratedReference(proxy) {
+		return g.schemaRefType(schemaPath{{Name: operation + suffix, SchemaProxy: proxy}}, proxy)
+	}
+
+	schema := proxy.Schema()
+	ptype, _ := extractPrimaryType(schema.Type)
+
+	switch ptype.Type {
+	case "array", "string", "integer", "number", "boolean":
+		if len(schema.OneOf) == 0 {
+			// Inline this type. We make the path non-root so that references
+			// are resolved.
+			path := schemaPath{
+				{Name: operation},
+				{Name: suffix, SchemaProxy: proxy},
+			}
+			return g.captured(func(g *generator) { g.generateSchema(path) })
+		}
+	}
+
+	name := operation + snakeToGo(suffix)
+	g.state.addGenerated(name, generateNamedSchema(g.state, schemaPath{
+		{Name: name, SchemaProxy: proxy},
+	}))
+	return name
+}
+
+// operationResponse returns the schema of the successful JSON response of the
+// given operation, or nil if it has none.
+func operationResponse(op *openapi.Operation) *openapibase.SchemaProxy {
+	if op.Responses == nil {
+		return nil
+	}
+
+	codesIter := orderedMap(op.Responses.Codes)
+
+	var proxy *openapibase.SchemaProxy
+	codesIter(func(code string, resp *openapi.Response) bool {
+		if !strings.HasPrefix(code, "2") {
+			return true
+		}
+		if media, ok := resp.Content["application/json"]; ok {
+			proxy = media.Schema
+		}
+		return false
+	})
+
+	return proxy
+}
+
+// mergeParameters merges the parameters of a path item with the parameters of
+// one of its operations. Operation parameters override path item parameters.
+func mergeParameters(itemParams, opParams []*openapi.Parameter) []*openapi.Parameter {
+	params := make([]*openapi.Parameter, 0, len(itemParams)+len(opParams))
+	params = append(params, itemParams...)
+	for _, param := range opParams {
+		i := slices.IndexFunc(params, func(p *openapi.Parameter) bool {
+			return p.Name == param.Name && p.In == param.In
+		})
+		if i != -1 {
+			params[i] = param
+		} else {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// operationName returns the Go method name of the given operation.
+func operationName(method, path string, op *openapi.Operation) string {
+	if op.OperationId != "" {
+		return snakeToGo(op.OperationId)
+	}
+
+	// Derive a name from the method and the static parts of the path, e.g.
+	// GET /channels/{channel_id}/messages becomes GetChannelsMessages.
+	name := snakeToGo(method)
+	for _, part := range strings.Split(path, "/") {
+		if part == "" || pathParamRe.MatchString(part) {
+			continue
+		}
+		name += snakeToGo(part)
+	}
+	return name
+}
+
+func snakeToUnexportedGo(s string) string {
+	s = snakeToGo(s)
+	if strings.IndexFunc(s, unicode.IsLower) == -1 {
+		// All upper-case, e.g. ID.
+		return strcases.SnakeNoGo(strings.ToLower(s))
+	}
+	return strcases.UnexportPascal(s)
+}
@@ -1,22 +1,149 @@
 package option
 
-// Optional is a type alias for optional values.
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Optional is an optional value. The zero value is an unset value.
 //
-// # Compatibility Note
+// Optional values are encoded in JSON as the value itself. Unset values are
+// encoded as null. To omit them instead, tag struct fields of this type with
+// omitempty and encode the struct using [MarshalStruct]. JSON null is decoded
+// as an unset value.
+type Optional[T any] struct {
+	v   T
+	set bool
+}
+
+// Some returns an optional value that is set to v.
+func Some[T any](v T) Optional[T] { return Optional[T]{v: v, set: true} }
+
+// None returns an unset optional value.
+func None[T any]() Optional[T] { return Optional[T]{} }
+
+// Map returns the result of f applied to the value of o if o is set.
+// Otherwise, an unset value is returned.
+func Map[T, U any](o Optional[T], f func(T) U) Optional[U] {
+	if !o.set {
+		return None[U]()
+	}
+	return Some(f(o.v))
+}
+
+// IsSome returns whether o is set.
+func (o Optional[T]) IsSome() bool { return o.set }
+
+// IsZero returns whether o is unset. It is used by [MarshalStruct] to omit
+// unset fields.
+func (o Optional[T]) IsZero() bool { return !o.set }
+
+// Get returns the value of o and whether it is set.
+func (o Optional[T]) Get() (T, bool) { return o.v, o.set }
+
+// OrElse returns the value of o if it is set. Otherwise, v is returned.
+func (o Optional[T]) OrElse(v T) T {
+	if o.set {
+		return o.v
+	}
+	return v
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.v)
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		*o = None[T]()
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*o = Some(v)
+	return nil
+}
+
+// Nullable is an optional value that may also be null. Unlike [Optional], it
+// distinguishes between an absent value and an explicit null, which some
+// endpoints use to clear a value. The zero value is an absent value.
 //
-// In the future, this type will be replaced with an opaque type. This means
-// that the type might not be a pointer anymore. Because of this, it is
-// recommended to use the methods provided by this package to interact with
-// this type.
-type Optional[T any] *optionalImpl[T]
+// Absent and null values are both encoded in JSON as null. To omit absent
+// values instead, tag struct fields of this type with omitempty and encode the
+// struct using [MarshalStruct]. JSON null is decoded as a null value, and a
+// missing field leaves the value absent.
+type Nullable[T any] struct {
+	v    T
+	set  bool
+	null bool
+}
+
+// Value returns a nullable value that is set to v.
+func Value[T any](v T) Nullable[T] { return Nullable[T]{v: v, set: true} }
+
+// ExplicitNull returns a nullable value that is explicitly null.
+func ExplicitNull[T any]() Nullable[T] { return Nullable[T]{set: true, null: true} }
+
+// Absent returns an absent nullable value.
+func Absent[T any]() Nullable[T] { return Nullable[T]{} }
+
+// IsSome returns whether n is set to a value.
+func (n Nullable[T]) IsSome() bool { return n.set && !n.null }
+
+// IsNull returns whether n is explicitly null.
+func (n Nullable[T]) IsNull() bool { return n.set && n.null }
+
+// IsAbsent returns whether n is absent.
+func (n Nullable[T]) IsAbsent() bool { return !n.set }
+
+// IsZero returns whether n is absent. It is used by [MarshalStruct] to omit
+// absent fields.
+func (n Nullable[T]) IsZero() bool { return !n.set }
+
+// Get returns the value of n and whether it is set to a value.
+func (n Nullable[T]) Get() (T, bool) { return n.v, n.IsSome() }
+
+// OrElse returns the value of n if it is set to a value. Otherwise, v is
+// returned.
+func (n Nullable[T]) OrElse(v T) T {
+	if n.IsSome() {
+		return n.v
+	}
+	return v
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if !n.IsSome() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.v)
+}
 
-type optionalImpl[T any] struct{ v T }
+// UnmarshalJSON implements [json.Unmarshaler].
+func (n *Nullable[T]) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		*n = ExplicitNull[T]()
+		return nil
+	}
 
-// Some returns an optional value from a non-nil value.
-func Some[T any](v T) Optional[T] { return Optional[T](&optionalImpl[T]{v}) }
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
 
-// None returns a nil optional value.
-func None[T any]() Optional[T] { return nil }
+	*n = Value(v)
+	return nil
+}
 
 // PtrTo returns a pointer to the given value.
 func PtrTo[T any](v T) *T { return &v }
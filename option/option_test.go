@@ -0,0 +1,110 @@
+package option
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+type optionalObject struct {
+	A Optional[int] `json:"a,omitempty"`
+}
+
+func (o optionalObject) MarshalJSON() ([]byte, error) { return MarshalStruct(o) }
+
+func TestOptionalJSON(t *testing.T) {
+	type object = optionalObject
+
+	tests := []struct {
+		name string
+		v    object
+		json string
+	}{
+		{"unset", object{}, `{}`},
+		{"zero", object{A: Some(0)}, `{"a":0}`},
+		{"value", object{A: Some(42)}, `{"a":42}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := json.Marshal(test.v)
+			assert.NoError(t, err)
+			assert.Equal(t, test.json, string(b))
+
+			var v object
+			assert.NoError(t, json.Unmarshal(b, &v))
+			assert.Equal(t, test.v, v)
+		})
+	}
+
+	var v object
+	assert.NoError(t, json.Unmarshal([]byte(`{"a":null}`), &v))
+	assert.False(t, v.A.IsSome())
+}
+
+func TestOptional(t *testing.T) {
+	v, ok := None[int]().Get()
+	assert.Equal(t, 0, v)
+	assert.False(t, ok)
+
+	v, ok = Some(1).Get()
+	assert.Equal(t, 1, v)
+	assert.True(t, ok)
+
+	assert.Equal(t, 2, None[int]().OrElse(2))
+	assert.Equal(t, Some("1"), Map(Some(1), func(v int) string { return "1" }))
+	assert.Equal(t, None[string](), Map(None[int](), func(v int) string { return "1" }))
+
+	assert.True(t, Some(1) == Some(1))
+	assert.False(t, Some(0) == None[int]())
+}
+
+type nullableObject struct {
+	A Nullable[string] `json:"a,omitempty"`
+}
+
+func (o nullableObject) MarshalJSON() ([]byte, error) { return MarshalStruct(o) }
+
+func TestNullableJSON(t *testing.T) {
+	type object = nullableObject
+
+	tests := []struct {
+		name string
+		v    object
+		json string
+	}{
+		{"absent", object{A: Absent[string]()}, `{}`},
+		{"null", object{A: ExplicitNull[string]()}, `{"a":null}`},
+		{"value", object{A: Value("topic")}, `{"a":"topic"}`},
+		{"empty", object{A: Value("")}, `{"a":""}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := json.Marshal(test.v)
+			assert.NoError(t, err)
+			assert.Equal(t, test.json, string(b))
+
+			var v object
+			assert.NoError(t, json.Unmarshal(b, &v))
+			assert.Equal(t, test.v, v)
+		})
+	}
+}
+
+func TestNullable(t *testing.T) {
+	assert.True(t, Absent[int]().IsAbsent())
+	assert.True(t, ExplicitNull[int]().IsNull())
+	assert.False(t, ExplicitNull[int]().IsSome())
+	assert.True(t, Value(0).IsSome())
+
+	v, ok := ExplicitNull[int]().Get()
+	assert.Equal(t, 0, v)
+	assert.False(t, ok)
+	assert.Equal(t, 2, ExplicitNull[int]().OrElse(2))
+	assert.Equal(t, 1, Value(1).OrElse(2))
+
+	assert.True(t, ExplicitNull[int]() == ExplicitNull[int]())
+	assert.False(t, ExplicitNull[int]() == Absent[int]())
+}
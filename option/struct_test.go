@@ -0,0 +1,101 @@
+package option
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+type StructEmbedded struct {
+	B Optional[string] `json:"b,omitempty"`
+	C int              `json:"c"`
+}
+
+type structMarshaler struct{}
+
+func (structMarshaler) MarshalJSON() ([]byte, error) { return []byte(`"custom"`), nil }
+
+type structObject struct {
+	StructEmbedded
+	A Optional[int] `json:"a,omitempty"`
+	D struct {
+		E Optional[bool] `json:"e,omitempty"`
+	} `json:"d"`
+	F []struct {
+		G Optional[bool] `json:"g,omitempty"`
+	} `json:"f"`
+	H map[string]Optional[int] `json:"h,omitempty"`
+	I structMarshaler          `json:"i"`
+	J time.Time                `json:"j"`
+	K []byte                   `json:"k,omitempty"`
+	L *int                     `json:"l"`
+	M string                   `json:"-"`
+	N string                   `json:",omitempty"`
+	o string
+}
+
+func TestMarshalStruct(t *testing.T) {
+	var v structObject
+	v.C = 1
+	v.F = make([]struct {
+		G Optional[bool] `json:"g,omitempty"`
+	}, 2)
+	v.F[1].G = Some(true)
+	v.H = map[string]Optional[int]{"y": Some(1), "x": None[int]()}
+	v.M = "hidden"
+	v.o = "hidden"
+
+	b, err := MarshalStruct(v)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`{"c":1,"d":{},"f":[{},{"g":true}],"h":{"x":null,"y":1},"i":"custom","j":"0001-01-01T00:00:00Z","l":null}`,
+		string(b))
+
+	v.B = Some("b")
+	v.A = Some(0)
+	v.D.E = Some(false)
+	v.K = []byte("k")
+	v.N = "n"
+
+	b, err = MarshalStruct(&v)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`{"b":"b","c":1,"a":0,"d":{"e":false},"f":[{},{"g":true}],"h":{"x":null,"y":1},"i":"custom","j":"0001-01-01T00:00:00Z","k":"aw==","l":null,"N":"n"}`,
+		string(b))
+}
+
+func TestMarshalStructLikeJSON(t *testing.T) {
+	type object struct {
+		A string            `json:"a"`
+		B []int             `json:"b"`
+		C map[int]string    `json:"c"`
+		D *struct{ E int }  `json:"d,omitempty"`
+		F any               `json:"f"`
+		G map[string]string `json:"g,omitempty"`
+	}
+
+	v := object{
+		A: "<a>",
+		B: []int{1, 2},
+		C: map[int]string{2: "b", 1: "a"},
+		F: []string{"f"},
+	}
+
+	want, err := json.Marshal(v)
+	assert.NoError(t, err)
+
+	got, err := MarshalStruct(v)
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestMarshalStructNonStruct(t *testing.T) {
+	_, err := MarshalStruct(1)
+	assert.Error(t, err)
+
+	b, err := MarshalStruct((*structObject)(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "null", string(b))
+}
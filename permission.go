@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"libdb.so/arikawa-generator/internal/docread"
+)
+
+var knownPermissions *docread.FlagTable
+
+func scrapePermissionDocs() error {
+	t, err := docread.ScrapePermissionsFile(permissionsDocsFile)
+	if err != nil {
+		return err
+	}
+	knownPermissions = t
+	logSkippedFlags([]docread.FlagTable{*t})
+	return nil
+}
+
+// permissionFields are the names of the string fields that are generated as
+// Permissions.
+var permissionFields = NewSet("permissions", "allow", "deny")
+
+// isPermissionsField returns whether the string schema at the given path is
+// generated as Permissions.
+func isPermissionsField(path schemaPath) bool {
+	// Nullable fields may be wrapped in a oneOf of null and the string, so
+	// the string is below the field.
+	for len(path) > 1 && strings.HasPrefix(path[len(path)-1].Name, "_oneOf[") {
+		path = path.Parent()
+	}
+	return permissionFields.Has(path.CurrentName())
+}
+
+const permissionsJSONCode = `// MarshalJSON implements [json.Marshaler]. Permissions are encoded as a
+// string, since they may not fit in a JSON number.
+func (f Permissions) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(strconv.FormatUint(uint64(f), 10))), nil
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. Both strings and numbers are
+// accepted. JSON null is decoded as the zero value.
+func (f *Permissions) UnmarshalJSON(b []byte) error {
+	str := string(b)
+	if str == "null" {
+		*f = 0
+		return nil
+	}
+
+	v, err := strconv.ParseUint(strings.Trim(str, ` + "`\"`" + `), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid permissions %s: %w", str, err)
+	}
+
+	*f = Permissions(v)
+	return nil
+}
+
+`
+
+// generatePermissions generates the Permissions bitfield. Its constants are
+// generated from the given permission table, which may be nil if the
+// permissions aren't documented.
+func generatePermissions(state *generateState, table *docread.FlagTable) {
+	var b strings.Builder
+	g := &generator{output: &b, state: state}
+
+	var flags []flagConst
+	if table != nil {
+		flags = permissionFlags(state, *table)
+	}
+
+	fmt.Fprintln(g.output, "// Permissions is a bitfield of permissions.")
+	if table != nil {
+		fmt.Fprintln(g.output, "//")
+		fmt.Fprint(g.output, docSourceComment(filepath.Dir(permissionsDocsFile), table.Source))
+	}
+	fmt.Fprintf(g.output, "type Permissions %s\n", bitfieldType)
+	g.writeBitfieldBody("Permissions", flags)
+	fmt.Fprint(g.output, "\n\n")
+	b.WriteString(permissionsJSONCode)
+
+	state.addGenerated("Permissions", b.String())
+}
+
+// permissionFlags returns the constants of the permissions in the given
+// table.
+func permissionFlags(state *generateState, table docread.FlagTable) []flagConst {
+	log := hclog.FromContext(state.ctx)
+	log.Debug("generating permissions", "sections", table.Sections)
+
+	flags := make([]flagConst, 0, len(table.Flags))
+	for _, row := range table.Flags {
+		if bits.OnesCount64(row.Value) != 1 {
+			log.Warn("skipping permission that isn't a single bit",
+				"permission", row.Name,
+				"value", row.Expr)
+			continue
+		}
+		flags = append(flags, flagConst{
+			Name:        "Permission" + constToGo(row.Name),
+			Bit:         bits.TrailingZeros64(row.Value),
+			Doc:         row.Description,
+			Deprecation: row.Deprecation,
+		})
+	}
+	return flags
+}
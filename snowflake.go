@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// snowflakeCode contains the base Snowflake type. It is always generated.
+const snowflakeCode = `// Snowflake is a unique ID used by Discord. Each snowflake contains the time
+// that it was created at. It is encoded in JSON as a string.
+type Snowflake uint64
+
+// DiscordEpoch is the Discord epoch, the time that snowflake timestamps are
+// relative to. It is the first millisecond of 2015.
+const DiscordEpoch = 1420070400000
+
+// ParseSnowflake parses a snowflake from its decimal string form.
+func ParseSnowflake(s string) (Snowflake, error) {
+	u, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid snowflake %q: %w", s, err)
+	}
+	return Snowflake(u), nil
+}
+
+// String returns the decimal string form of s.
+func (s Snowflake) String() string {
+	return strconv.FormatUint(uint64(s), 10)
+}
+
+// IsValid returns whether s is a valid snowflake. The zero value is not valid.
+func (s Snowflake) IsValid() bool {
+	return s != 0
+}
+
+// Time returns the time that s was created at.
+func (s Snowflake) Time() time.Time {
+	return time.UnixMilli(int64(s>>22) + DiscordEpoch)
+}
+
+// Worker returns the ID of the internal worker that generated s.
+func (s Snowflake) Worker() uint8 {
+	return uint8(s >> 17 & 0x1F)
+}
+
+// PID returns the ID of the internal process that generated s.
+func (s Snowflake) PID() uint8 {
+	return uint8(s >> 12 & 0x1F)
+}
+
+// Increment returns the increment of s. It is incremented for every snowflake
+// generated by the same process.
+func (s Snowflake) Increment() uint16 {
+	return uint16(s & 0xFFF)
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (s Snowflake) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(s.String())), nil
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. Both strings and numbers are
+// accepted. JSON null is decoded as the zero value.
+func (s *Snowflake) UnmarshalJSON(b []byte) error {
+	str := string(b)
+	if str == "null" {
+		*s = 0
+		return nil
+	}
+
+	v, err := ParseSnowflake(strings.Trim(str, ` + "`\"`" + `))
+	if err != nil {
+		return err
+	}
+
+	*s = v
+	return nil
+}
+
+`
+
+// generateSnowflakes generates the base Snowflake type and an ID type for each
+// known kind of snowflake.
+func generateSnowflakes(state *generateState) {
+	state.addGenerated("Snowflake", snowflakeCode)
+
+	for kind := range snowflakes {
+		name := kind + "ID"
+		state.addGenerated(name, generateSnowflakeKind(name, kind))
+	}
+}
+
+func generateSnowflakeKind(name, kind string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s is the ID of a %s. It can be converted to and from a\n", name, kind)
+	fmt.Fprintf(&b, "// [Snowflake].\n")
+	fmt.Fprintf(&b, "type %s Snowflake\n\n", name)
+
+	fmt.Fprintf(&b, "// Snowflake returns id as a [Snowflake].\n")
+	fmt.Fprintf(&b, "func (id %s) Snowflake() Snowflake { return Snowflake(id) }\n\n", name)
+
+	fmt.Fprintf(&b, "// String returns the decimal string form of id.\n")
+	fmt.Fprintf(&b, "func (id %s) String() string { return Snowflake(id).String() }\n\n", name)
+
+	fmt.Fprintf(&b, "// IsValid returns whether id is valid. The zero value is not valid.\n")
+	fmt.Fprintf(&b, "func (id %s) IsValid() bool { return Snowflake(id).IsValid() }\n\n", name)
+
+	fmt.Fprintf(&b, "// Time returns the time that id was created at.\n")
+	fmt.Fprintf(&b, "func (id %s) Time() time.Time { return Snowflake(id).Time() }\n\n", name)
+
+	fmt.Fprintf(&b, "// MarshalJSON implements [json.Marshaler].\n")
+	fmt.Fprintf(&b, "func (id %s) MarshalJSON() ([]byte, error) {\n", name)
+	fmt.Fprintf(&b, "\treturn Snowflake(id).MarshalJSON()\n")
+	fmt.Fprintf(&b, "}\n\n")
+
+	fmt.Fprintf(&b, "// UnmarshalJSON implements [json.Unmarshaler].\n")
+	fmt.Fprintf(&b, "func (id *%s) UnmarshalJSON(b []byte) error {\n", name)
+	fmt.Fprintf(&b, "\treturn (*Snowflake)(id).UnmarshalJSON(b)\n")
+	fmt.Fprintf(&b, "}\n\n")
+
+	return b.String()
+}
+
+// sortedSnowflakeKinds returns the known snowflake kinds with the longest
+// kinds first, so that the most specific kind is matched first.
+func sortedSnowflakeKinds() []string {
+	kinds := make([]string, 0, len(snowflakes))
+	for kind := range snowflakes {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if len(kinds[i]) != len(kinds[j]) {
+			return len(kinds[i]) > len(kinds[j])
+		}
+		return kinds[i] < kinds[j]
+	})
+	return kinds
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	stdpath "path"
+
+	"golang.org/x/exp/slices"
+	"libdb.so/arikawa-generator/internal/cmt"
+
+	openapibase "github.com/pb33f/libopenapi/datamodel/high/base"
+)
+
+// unionHelpersCode contains the helpers used by the generated Parse functions
+// of unions. It is generated once if there are any unions.
+const unionHelpersCode = `// jsonKind returns the kind of the given JSON value, which is one of "object",
+// "array", "string", "number", "boolean" or "null".
+func jsonKind(b []byte) string {
+	for _, c := range b {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{':
+			return "object"
+		case '[':
+			return "array"
+		case '"':
+			return "string"
+		case 't', 'f':
+			return "boolean"
+		case 'n':
+			return "null"
+		default:
+			return "number"
+		}
+	}
+	return ""
+}
+
+// jsonObjectShape describes the fields that a JSON object must have to be
+// decoded into a particular type.
+type jsonObjectShape struct {
+	// Required is the list of fields that must be present.
+	Required []string
+	// Known is the list of all fields that may be present.
+	Known []string
+	// Consts maps fields to the JSON values that they must have.
+	Consts map[string]string
+}
+
+// score returns how well the given object fits the shape, which is the number
+// of its fields that the shape knows, or -1 if a required field is missing or
+// a constant doesn't match. Unknown fields are ignored, since the API may add
+// fields at any time.
+func (s jsonObjectShape) score(fields map[string]json.RawMessage) int {
+	for _, name := range s.Required {
+		if _, ok := fields[name]; !ok {
+			return -1
+		}
+	}
+	for name, value := range s.Consts {
+		if string(fields[name]) != value {
+			return -1
+		}
+	}
+	score := 0
+	for _, known := range s.Known {
+		if _, ok := fields[known]; ok {
+			score++
+		}
+	}
+	return score
+}
+
+// bestJSONShape returns the index of the shape that fits the given object
+// best, or -1 if none of them fits. Ties go to the earlier shape.
+func bestJSONShape(fields map[string]json.RawMessage, shapes ...jsonObjectShape) int {
+	best, bestScore := -1, -1
+	for i, shape := range shapes {
+		if score := shape.score(fields); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+`
+
+// unionVariant describes what a variant of a union looks like in JSON.
+type unionVariant struct {
+	// Name is the Go type name of the variant.
+	Name string
+	// Kind is the JSON kind of the variant as returned by jsonKind. It is
+	// empty if the kind is not known.
+	Kind string
+	// Required, Known and Consts describe the fields of an object variant.
+	// See jsonObjectShape.
+	Required []string
+	Known    []string
+	Consts   map[string]string
+}
+
+func (g *generator) unionVariant(name string, proxy *openapibase.SchemaProxy) unionVariant {
+	schema := proxy.Schema()
+	ptype, _ := extractPrimaryType(schema.Type)
+
+	v := unionVariant{Name: name}
+	switch ptype.Type {
+	case "integer", "number":
+		v.Kind = "number"
+	case "object":
+		v.Kind = "object"
+		v.Consts = map[string]string{}
+		for _, property := range objectProperties(schema) {
+			v.Known = append(v.Known, property.Name)
+			if property.Required {
+				v.Required = append(v.Required, property.Name)
+			}
+			if c, err := schemaConst(property.Proxy.Schema()); err == nil && c != "null" {
+				v.Consts[property.Name] = c
+			}
+		}
+	case "array", "string", "boolean", "null":
+		v.Kind = ptype.Type
+	}
+	return v
+}
+
+// generateUnionJSON generates the Wrap type and the Parse function of the
+// union with the given name. If the union has a discriminator, then the Parse
+// function dispatches on it. Otherwise, it picks the variant that matches the
+// kind and shape of the JSON value.
+func (g *generator) generateUnionJSON(path schemaPath, unionName string, names []string, proxies []*openapibase.SchemaProxy) {
+	g.state.addGenerated("jsonKind", unionHelpersCode)
+
+	wrapName := unionName + "Wrap"
+	parseName := "Parse" + unionName
+
+	fmt.Fprint(g.output, cmt.Prettify(wrapName, fmt.Sprintf(
+		"wraps a [%s] so that it can be encoded and decoded as JSON",
+		unionName), cmt.Opts{}))
+	fmt.Fprintf(g.output, "type %s struct {\n", wrapName)
+	fmt.Fprintf(g.output, "\t%s\n", unionName)
+	fmt.Fprintf(g.output, "}\n\n")
+
+	fmt.Fprintf(g.output, "// MarshalJSON implements [json.Marshaler].\n")
+	fmt.Fprintf(g.output, "func (w %s) MarshalJSON() ([]byte, error) {\n", wrapName)
+	fmt.Fprintf(g.output, "\treturn json.Marshal(w.%s)\n", unionName)
+	fmt.Fprintf(g.output, "}\n\n")
+
+	fmt.Fprintf(g.output, "// UnmarshalJSON implements [json.Unmarshaler].\n")
+	fmt.Fprintf(g.output, "func (w *%s) UnmarshalJSON(b []byte) (err error) {\n", wrapName)
+	fmt.Fprintf(g.output, "\tw.%s, err = %s(b)\n", unionName, parseName)
+	fmt.Fprintf(g.output, "\treturn\n")
+	fmt.Fprintf(g.output, "}\n\n")
+
+	if discriminator := path.Current().Discriminator; discriminator != nil && discriminator.PropertyName != "" {
+		g.generateDiscriminatedParse(path, unionName, names, proxies, discriminator)
+		return
+	}
+
+	variants := make([]unionVariant, len(proxies))
+	for i, proxy := range proxies {
+		variants[i] = g.unionVariant(names[i], proxy)
+	}
+
+	fmt.Fprint(g.output, cmt.Prettify(parseName, fmt.Sprintf(
+		"decodes a [%s] from the given JSON. The type of the value is chosen "+
+			"by the kind and the fields of the JSON value. JSON null is "+
+			"decoded as a nil %[1]s",
+		unionName), cmt.Opts{}))
+	fmt.Fprintf(g.output, "func %s(b []byte) (%s, error) {\n", parseName, unionName)
+	fmt.Fprintf(g.output, "\tswitch jsonKind(b) {\n")
+
+	var kinds []string
+	for _, variant := range variants {
+		if variant.Kind != "" && !slices.Contains(kinds, variant.Kind) {
+			kinds = append(kinds, variant.Kind)
+		}
+	}
+	if !slices.Contains(kinds, "null") {
+		fmt.Fprintf(g.output, "\tcase \"null\":\n")
+		fmt.Fprintf(g.output, "\t\treturn nil, nil\n")
+	}
+
+	for _, kind := range kinds {
+		fmt.Fprintf(g.output, "\tcase %q:\n", kind)
+
+		if kind != "object" {
+			// Only the first variant of each kind can ever be decoded.
+			i := slices.IndexFunc(variants, func(v unionVariant) bool { return v.Kind == kind })
+			g.writeUnionDecode(variants[i].Name, "\t\t")
+			continue
+		}
+
+		objects := make([]unionVariant, 0, len(variants))
+		for _, variant := range variants {
+			if variant.Kind == "object" {
+				objects = append(objects, variant)
+			}
+		}
+
+		// Prefer the most specific variants on ties, so that a variant whose
+		// fields are a subset of another's doesn't shadow it.
+		sort.SliceStable(objects, func(i, j int) bool {
+			if len(objects[i].Consts) != len(objects[j].Consts) {
+				return len(objects[i].Consts) > len(objects[j].Consts)
+			}
+			return len(objects[i].Required) > len(objects[j].Required)
+		})
+
+		fmt.Fprintf(g.output, "\t\tvar fields map[string]json.RawMessage\n")
+		fmt.Fprintf(g.output, "\t\tif err := json.Unmarshal(b, &fields); err != nil {\n")
+		fmt.Fprintf(g.output, "\t\t\treturn nil, err\n")
+		fmt.Fprintf(g.output, "\t\t}\n")
+		fmt.Fprintf(g.output, "\t\tswitch bestJSONShape(fields,\n")
+		for _, object := range objects {
+			fmt.Fprintf(g.output, "\t\t\t%s,\n", object.shapeCode())
+		}
+		fmt.Fprintf(g.output, "\t\t) {\n")
+		for i, object := range objects {
+			fmt.Fprintf(g.output, "\t\tcase %d:\n", i)
+			g.writeUnionDecode(object.Name, "\t\t\t")
+		}
+		fmt.Fprintf(g.output, "\t\t}\n")
+	}
+
+	fmt.Fprintf(g.output, "\t}\n")
+
+	// Variants with an unknown kind are tried in order.
+	for _, variant := range variants {
+		if variant.Kind != "" {
+			continue
+		}
+		fmt.Fprintf(g.output, "\t{\n")
+		fmt.Fprintf(g.output, "\t\tvar v %s\n", variant.Name)
+		fmt.Fprintf(g.output, "\t\tif err := json.Unmarshal(b, &v); err == nil {\n")
+		fmt.Fprintf(g.output, "\t\t\treturn v, nil\n")
+		fmt.Fprintf(g.output, "\t\t}\n")
+		fmt.Fprintf(g.output, "\t}\n")
+	}
+
+	fmt.Fprintf(g.output, "\treturn nil, fmt.Errorf(\"cannot decode JSON %%s as %s\", jsonKind(b))\n", unionName)
+	fmt.Fprintf(g.output, "}\n\n")
+}
+
+// generateDiscriminatedParse generates the Parse function of a union that has
+// a discriminator. The discriminator property is read first, and the value is
+// decoded straight into the type that it maps to. A type for the discriminator
+// values is also generated, along with a constant for each value.
+func (g *generator) generateDiscriminatedParse(
+	path schemaPath, unionName string, names []string,
+	proxies []*openapibase.SchemaProxy, discriminator *openapibase.Discriminator) {
+
+	discName := unionName + "Discriminator"
+	parseName := "Parse" + unionName
+
+	mapping := discriminator.Mapping
+	if len(mapping) == 0 {
+		// Without a mapping, the values are the names of the schemas.
+		mapping = make(map[string]string, len(proxies))
+		for _, proxy := range proxies {
+			if proxyIsGeneratedReference(proxy) {
+				mapping[stdpath.Base(proxy.GetReference())] = proxy.GetReference()
+			}
+		}
+	}
+
+	// The discriminator is an integer if the property is an integer in the
+	// variants, which is the case for most of Discord's unions.
+	discType := "string"
+	for _, proxy := range proxies {
+		property, ok := proxy.Schema().Properties[discriminator.PropertyName]
+		if ok && slices.Contains(property.Schema().Type, "integer") {
+			discType = "int"
+			break
+		}
+	}
+
+	type discriminatorValue struct {
+		Const   string // Go constant name
+		Value   string // Go constant value
+		Variant string // Go type name
+	}
+
+	var values []discriminatorValue
+	mappingIter := orderedMap(mapping)
+	mappingIter(func(value, ref string) bool {
+		if !strings.Contains(ref, "/") {
+			ref = pathSchemas + "/" + ref
+		}
+		variant := g.schemaRefName(ref)
+		if !slices.Contains(names, variant) {
+			g.error(fmt.Errorf(
+				"discriminator of %s maps %q to %s, which is not in the union",
+				path, value, variant))
+			return true
+		}
+
+		goValue := fmt.Sprintf("%q", value)
+		if discType == "int" {
+			if _, err := strconv.Atoi(value); err != nil {
+				g.error(fmt.Errorf(
+					"discriminator of %s has non-integer value %q", path, value))
+				return true
+			}
+			goValue = value
+		}
+
+		constName := discName + variant
+		if slices.ContainsFunc(values, func(v discriminatorValue) bool { return v.Const == constName }) {
+			constName += constToGo(value)
+		}
+
+		values = append(values, discriminatorValue{
+			Const:   constName,
+			Value:   goValue,
+			Variant: variant,
+		})
+		return true
+	})
+
+	if discType == "int" {
+		// Sort numerically rather than lexically.
+		sort.SliceStable(values, func(i, j int) bool {
+			a, _ := strconv.Atoi(values[i].Value)
+			b, _ := strconv.Atoi(values[j].Value)
+			return a < b
+		})
+	}
+
+	fmt.Fprint(g.output, cmt.Prettify(discName, fmt.Sprintf(
+		"is the value of the %q property that determines the type of a [%s]",
+		discriminator.PropertyName, unionName), cmt.Opts{}))
+	fmt.Fprintf(g.output, "type %s %s\n\n", discName, discType)
+
+	fmt.Fprintln(g.output, "const (")
+	for _, value := range values {
+		fmt.Fprintf(g.output, "\t%s %s = %s\n", value.Const, discName, value.Value)
+	}
+	fmt.Fprint(g.output, ")\n\n")
+
+	fmt.Fprint(g.output, cmt.Prettify(parseName, fmt.Sprintf(
+		"decodes a [%s] from the given JSON. The type of the value is chosen "+
+			"by its %q property. JSON null is decoded as a nil %[1]s",
+		unionName, discriminator.PropertyName), cmt.Opts{}))
+	fmt.Fprintf(g.output, "func %s(b []byte) (%s, error) {\n", parseName, unionName)
+	fmt.Fprintf(g.output, "\tif jsonKind(b) == \"null\" {\n")
+	fmt.Fprintf(g.output, "\t\treturn nil, nil\n")
+	fmt.Fprintf(g.output, "\t}\n\n")
+	fmt.Fprintf(g.output, "\tvar fields map[string]json.RawMessage\n")
+	fmt.Fprintf(g.output, "\tif err := json.Unmarshal(b, &fields); err != nil {\n")
+	fmt.Fprintf(g.output, "\t\treturn nil, err\n")
+	fmt.Fprintf(g.output, "\t}\n\n")
+	fmt.Fprintf(g.output, "\trawDiscriminator, ok := fields[%q]\n", discriminator.PropertyName)
+	fmt.Fprintf(g.output, "\tif !ok {\n")
+	fmt.Fprintf(g.output, "\t\treturn nil, fmt.Errorf(\"%s is missing %s\")\n", unionName, discriminator.PropertyName)
+	fmt.Fprintf(g.output, "\t}\n\n")
+	fmt.Fprintf(g.output, "\tvar discriminator %s\n", discName)
+	fmt.Fprintf(g.output, "\tif err := json.Unmarshal(rawDiscriminator, &discriminator); err != nil {\n")
+	fmt.Fprintf(g.output, "\t\treturn nil, fmt.Errorf(\"%s has invalid %s: %%w\", err)\n", unionName, discriminator.PropertyName)
+	fmt.Fprintf(g.output, "\t}\n\n")
+	fmt.Fprintf(g.output, "\tswitch discriminator {\n")
+	for _, value := range values {
+		fmt.Fprintf(g.output, "\tcase %s:\n", value.Const)
+		g.writeUnionDecode(value.Variant, "\t\t")
+	}
+	fmt.Fprintf(g.output, "\t}\n\n")
+	fmt.Fprintf(g.output, "\treturn nil, fmt.Errorf(\"unknown %s %s %%v\", discriminator)\n", unionName, discriminator.PropertyName)
+	fmt.Fprintf(g.output, "}\n\n")
+}
+
+func (g *generator) writeUnionDecode(name, indent string) {
+	fmt.Fprintf(g.output, "%svar v %s\n", indent, name)
+	fmt.Fprintf(g.output, "%serr := json.Unmarshal(b, &v)\n", indent)
+	fmt.Fprintf(g.output, "%sreturn v, err\n", indent)
+}
+
+// shapeCode returns the Go expression of the jsonObjectShape of v.
+func (v unionVariant) shapeCode() string {
+	var b strings.Builder
+	b.WriteString("jsonObjectShape{")
+	if len(v.Required) > 0 {
+		fmt.Fprintf(&b, "Required: %s, ", goStringSlice(v.Required))
+	}
+	fmt.Fprintf(&b, "Known: %s", goStringSlice(v.Known))
+	if len(v.Consts) > 0 {
+		b.WriteString(", Consts: map[string]string{")
+		constsIter := orderedMap(v.Consts)
+		constsIter(func(name, value string) bool {
+			fmt.Fprintf(&b, "%q: %q, ", name, value)
+			return true
+		})
+		b.WriteString("}")
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func goStringSlice(strs []string) string {
+	quoted := make([]string, len(strs))
+	for i, s := range strs {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+// schemaRefType returns the Go type used to refer to the schema that the given
+// proxy references. Unions are referred to using their Wrap type, since their
+// interface type cannot be decoded from JSON.
+func (g *generator) schemaRefType(path schemaPath, proxy *openapibase.SchemaProxy) string {
+	name := g.schemaRefName(proxy.GetReference())
+	if g.proxyIsUnion(path, proxy) {
+		name += "Wrap"
+	}
+	return name
+}
+
+// proxyIsUnion returns whether the given schema is generated as a union
+// interface. It mirrors the logic of generateSchema.
+func (g *generator) proxyIsUnion(path schemaPath, proxy *openapibase.SchemaProxy) bool {
+	schema := proxy.Schema()
+	if schema == nil || len(schema.Type) > 0 || schema.AllOf != nil {
+		return false
+	}
+
+	switch {
+	case schema.AnyOf != nil:
+		_, merged := g.mergeAnyOfProperties(path, schema.AnyOf)
+		return !merged
+	case schema.OneOf != nil:
+		return !oneOfIsNullable(schema.OneOf)
+	default:
+		return false
+	}
+}
+
+// oneOfIsNullable returns whether the given oneOf is Discord's special case of
+// [{type: null}, T], which is generated as a pointer to T.
+func oneOfIsNullable(proxies []*openapibase.SchemaProxy) bool {
+	return oneOfNullIndex(proxies) != -1
+}
+
+func oneOfNullIndex(proxies []*openapibase.SchemaProxy) int {
+	if len(proxies) != 2 {
+		return -1
+	}
+	return slices.IndexFunc(proxies, func(proxy *openapibase.SchemaProxy) bool {
+		schema := proxy.Schema()
+		return slices.Equal(schema.Type, []string{"null"})
+	})
+}
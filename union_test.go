@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// unionHelpersTestMain decodes objects using the generated union helpers and
+// prints the index of the shape that each of them is decoded as.
+const unionHelpersTestMain = `func main() {
+	shapes := []jsonObjectShape{
+		{Required: []string{"type", "id"}, Known: []string{"type", "id"}, Consts: map[string]string{"type": "1"}},
+		{Required: []string{"type"}, Known: []string{"type", "id", "label"}, Consts: map[string]string{"type": "2"}},
+		{Required: []string{"id"}, Known: []string{"id", "label"}},
+	}
+	for _, object := range []string{
+		` + "`" + `{"type": 1, "id": "a"}` + "`" + `,
+		` + "`" + `{"type": 1, "id": "a", "added_later": true}` + "`" + `,
+		` + "`" + `{"type": 2, "label": "b", "added_later": true}` + "`" + `,
+		` + "`" + `{"id": "a", "label": "b"}` + "`" + `,
+		` + "`" + `{"type": 3, "added_later": true}` + "`" + `,
+	} {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(object), &fields); err != nil {
+			panic(err)
+		}
+		fmt.Println(bestJSONShape(fields, shapes...))
+	}
+}
+`
+
+func TestUnionHelpers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test that runs the go command in short mode")
+	}
+
+	dir := t.TempDir()
+	src := "package main\n\nimport (\n\t\"encoding/json\"\n\t\"fmt\"\n)\n\n" +
+		unionHelpersCode + unionHelpersTestMain
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0o644))
+
+	cmd := exec.Command("go", "run", "main.go")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(out))
+	assert.Equal(t, "0\n0\n1\n2\n-1\n", string(out))
+}
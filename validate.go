@@ -0,0 +1,414 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/gotk4/gir/girgen/strcases"
+	"github.com/hashicorp/go-hclog"
+)
+
+const validationCode = `// ValidationError is returned by Validate methods when a value violates a
+// constraint of the API.
+type ValidationError struct {
+	// Path is the JSON path of the invalid value, e.g. embeds[0].title. It is
+	// empty if the value itself is invalid.
+	Path string
+	// Reason describes the constraint that the value violates.
+	Reason string
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Reason
+	}
+	return e.Path + ": " + e.Reason
+}
+
+func validationPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func validationIndex(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+`
+
+// maxValidationDepth is the maximum depth of referenced non-struct schemas
+// that are inlined into a validator. It stops recursive schemas.
+const maxValidationDepth = 8
+
+// structLayout is the layout of a named struct type.
+type structLayout struct {
+	Path       schemaPath
+	Embedded   []string
+	Properties []objectProperty
+}
+
+// addStruct records the layout of a named struct type so that a validator can
+// be generated for it later.
+func (e *generateState) addStruct(name string, layout structLayout) {
+	e.Lock()
+	defer e.Unlock()
+	e.structs[name] = layout
+}
+
+// addRequestType records that the named type is used as a request body.
+func (e *generateState) addRequestType(name string) {
+	e.Lock()
+	defer e.Unlock()
+	e.requestTypes.Add(name)
+}
+
+// generateValidators generates a Validate method for each named request type
+// and for each struct type that these types contain that has constraints.
+func generateValidators(state *generateState) {
+	names := make([]string, 0, len(state.structs))
+	for name := range state.structs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Find all types that need to be validated. A type needs to be validated
+	// if it has a constraint or contains a type that does, so we repeat until
+	// no new types are found.
+	validated := NewSet[string]()
+	for changed := true; changed; {
+		changed = false
+		for _, name := range names {
+			if validated.Has(name) {
+				continue
+			}
+			v := newValidatorWriter(state, name, validated)
+			v.writeStruct(state.structs[name])
+			if v.body.Len() > 0 {
+				validated.Add(name)
+				changed = true
+			}
+		}
+	}
+
+	// Generate validators for all request types, even ones without
+	// constraints, so that Validate can be called on any request. The types
+	// that they contain only get one if they have constraints.
+	queue := make([]string, 0, len(state.requestTypes))
+	for name := range state.requestTypes {
+		queue = append(queue, name)
+	}
+	sort.Strings(queue)
+
+	visited := NewSet(queue...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		v := newValidatorWriter(state, name, validated)
+		v.writeStruct(state.structs[name])
+		state.addGenerated(name+".Validate", v.code())
+
+		sort.Strings(v.refs)
+		for _, ref := range v.refs {
+			if !visited.Has(ref) {
+				visited.Add(ref)
+				queue = append(queue, ref)
+			}
+		}
+	}
+
+	if len(visited) > 0 {
+		state.addGenerated("ValidationError", validationCode)
+	}
+}
+
+// validatorWriter writes the body of a validate method.
+type validatorWriter struct {
+	*generator
+	name      string
+	validated Set[string]
+
+	body     strings.Builder
+	patterns []string // pattern variable declarations
+	refs     []string // validated types that are used
+	vars     int
+}
+
+func newValidatorWriter(state *generateState, name string, validated Set[string]) *validatorWriter {
+	return &validatorWriter{
+		generator: &generator{state: state},
+		name:      name,
+		validated: validated,
+	}
+}
+
+// code returns the whole generated code of the validator.
+func (v *validatorWriter) code() string {
+	var b strings.Builder
+	for _, pattern := range v.patterns {
+		b.WriteString(pattern)
+	}
+	if len(v.patterns) > 0 {
+		b.WriteString("\n")
+	}
+
+	if v.body.Len() == 0 {
+		fmt.Fprintf(&b, "// Validate validates v against the constraints of the API. %s has no\n", v.name)
+		fmt.Fprintf(&b, "// constraints, so it always returns nil.\n")
+		fmt.Fprintf(&b, "func (v %s) Validate() error {\n", v.name)
+		fmt.Fprintf(&b, "\treturn nil\n")
+		fmt.Fprintf(&b, "}\n\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "// Validate validates v against the constraints of the API. Each violation\n")
+	fmt.Fprintf(&b, "// is reported as a [*ValidationError].\n")
+	fmt.Fprintf(&b, "func (v %s) Validate() error {\n", v.name)
+	fmt.Fprintf(&b, "\treturn errors.Join(v.validate(\"\")...)\n")
+	fmt.Fprintf(&b, "}\n\n")
+
+	fmt.Fprintf(&b, "func (v %s) validate(path string) []error {\n", v.name)
+	fmt.Fprintf(&b, "\tvar errs []error\n")
+	b.WriteString(v.body.String())
+	fmt.Fprintf(&b, "\treturn errs\n")
+	fmt.Fprintf(&b, "}\n\n")
+	return b.String()
+}
+
+func (v *validatorWriter) newVar(prefix string) string {
+	v.vars++
+	return prefix + strconv.Itoa(v.vars)
+}
+
+func (v *validatorWriter) writeStruct(layout structLayout) {
+	for _, embedded := range layout.Embedded {
+		if v.validated.Has(embedded) {
+			v.refs = append(v.refs, embedded)
+			fmt.Fprintf(&v.body, "\terrs = append(errs, v.%s.validate(path)...)\n", embedded)
+		}
+	}
+	v.body.WriteString(v.properties(1, "v", "path", layout.Path, layout.Properties))
+}
+
+// properties returns the checks for the given properties of the struct value
+// expr.
+func (v *validatorWriter) properties(indent int, expr, pathExpr string, path schemaPath, properties []objectProperty) string {
+	var b strings.Builder
+	tabs := strings.Repeat("\t", indent)
+
+	for _, property := range properties {
+		fieldExpr := expr + "." + snakeToGo(property.Name)
+		fieldPath := fmt.Sprintf("validationPath(%s, %q)", pathExpr, property.Name)
+		fieldSchema := path.Push(property.Name, property.Proxy)
+
+		if property.Required {
+			b.WriteString(v.value(indent, fieldExpr, fieldPath, fieldSchema, false, 0))
+			continue
+		}
+
+		// Optional nullable fields are option.Nullable instead of pointers,
+		// so the value is never nil.
+		value := v.newVar("v")
+		checks := v.value(indent+1, value, fieldPath, fieldSchema, true, 0)
+		if checks == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%sif %s, ok := %s.Get(); ok {\n", tabs, value, fieldExpr)
+		b.WriteString(checks)
+		fmt.Fprintf(&b, "%s}\n", tabs)
+	}
+
+	return b.String()
+}
+
+// value returns the checks for the value expr of the schema at the given path.
+// If nonNullable is true, then the value is never a pointer even if the schema
+// is nullable.
+func (v *validatorWriter) value(indent int, expr, pathExpr string, path schemaPath, nonNullable bool, depth int) string {
+	var b strings.Builder
+	tabs := strings.Repeat("\t", indent)
+	log := hclog.FromContext(v.state.ctx)
+
+	proxy := path.CurrentProxy()
+	if proxyIsGeneratedReference(proxy) {
+		if v.proxyIsUnion(path, proxy) {
+			return ""
+		}
+
+		name := v.schemaRefName(proxy.GetReference())
+		if _, ok := v.state.structs[name]; ok {
+			if v.validated.Has(name) {
+				v.refs = append(v.refs, name)
+				fmt.Fprintf(&b, "%serrs = append(errs, %s.validate(%s)...)\n", tabs, expr, pathExpr)
+			}
+			return b.String()
+		}
+
+		if depth >= maxValidationDepth {
+			return ""
+		}
+		// Inline the constraints of non-struct types.
+		depth++
+	}
+
+	schema := path.Current()
+	ptype, err := extractPrimaryType(schema.Type)
+	if err != nil {
+		return ""
+	}
+
+	if ptype.Nullable && !nonNullable {
+		value := v.newVar("v")
+		inner := "*" + value
+		if ptype.Type == "object" {
+			// Fields of pointers to structs can be used directly.
+			inner = value
+		}
+		checks := v.value(indent+1, inner, pathExpr, path, true, depth)
+		if checks == "" {
+			return ""
+		}
+		fmt.Fprintf(&b, "%sif %s := %s; %s != nil {\n", tabs, value, expr, value)
+		b.WriteString(checks)
+		fmt.Fprintf(&b, "%s}\n", tabs)
+		return b.String()
+	}
+
+	check := func(cond, reason string) {
+		fmt.Fprintf(&b, "%sif %s {\n", tabs, cond)
+		fmt.Fprintf(&b, "%s\terrs = append(errs, &ValidationError{Path: %s, Reason: %q})\n", tabs, pathExpr, reason)
+		fmt.Fprintf(&b, "%s}\n", tabs)
+	}
+
+	switch ptype.Type {
+	case "string":
+		if schema.Format == "snowflake" || schema.Format == "date-time" || isPermissionsField(path) {
+			return ""
+		}
+
+		str := expr
+		if len(schema.OneOf) > 0 || proxyIsGeneratedReference(proxy) {
+			str = "string(" + expr + ")"
+		}
+
+		if schema.MinLength != nil && *schema.MinLength > 0 {
+			check(
+				fmt.Sprintf("utf8.RuneCountInString(%s) < %d", str, *schema.MinLength),
+				fmt.Sprintf("must be at least %s long", plural(*schema.MinLength, "character")))
+		}
+		if schema.MaxLength != nil {
+			check(
+				fmt.Sprintf("utf8.RuneCountInString(%s) > %d", str, *schema.MaxLength),
+				fmt.Sprintf("must be at most %s long", plural(*schema.MaxLength, "character")))
+		}
+		if schema.Pattern != "" {
+			if _, err := regexp.Compile(schema.Pattern); err != nil {
+				log.Warn("skipping pattern that isn't supported by Go",
+					"path", path.String(),
+					"pattern", schema.Pattern,
+					"error", err)
+				break
+			}
+
+			name := strcases.UnexportPascal(v.name) + "Pattern" + strconv.Itoa(len(v.patterns))
+			v.patterns = append(v.patterns,
+				fmt.Sprintf("var %s = regexp.MustCompile(%q)\n", name, schema.Pattern))
+			check(
+				fmt.Sprintf("!%s.MatchString(%s)", name, str),
+				fmt.Sprintf("must match the pattern %s", schema.Pattern))
+		}
+
+	case "integer", "number":
+		if len(schema.OneOf) > 0 {
+			// Enums and bitfields are already constrained by their values.
+			break
+		}
+
+		bound := func(f float64, op, reason string) {
+			if ptype.Type == "integer" && f != math.Trunc(f) {
+				log.Warn("skipping non-integer bound of integer schema",
+					"path", path.String(),
+					"bound", f)
+				return
+			}
+			s := strconv.FormatFloat(f, 'f', -1, 64)
+			check(fmt.Sprintf("%s %s %s", expr, op, s), reason+" "+s)
+		}
+
+		if schema.Minimum != nil {
+			exclusive := schema.ExclusiveMinimum != nil && schema.ExclusiveMinimum.IsA() && schema.ExclusiveMinimum.A
+			if exclusive {
+				bound(*schema.Minimum, "<=", "must be greater than")
+			} else {
+				bound(*schema.Minimum, "<", "must be at least")
+			}
+		}
+		if schema.ExclusiveMinimum != nil && schema.ExclusiveMinimum.IsB() {
+			bound(schema.ExclusiveMinimum.B, "<=", "must be greater than")
+		}
+		if schema.Maximum != nil {
+			exclusive := schema.ExclusiveMaximum != nil && schema.ExclusiveMaximum.IsA() && schema.ExclusiveMaximum.A
+			if exclusive {
+				bound(*schema.Maximum, ">=", "must be less than")
+			} else {
+				bound(*schema.Maximum, ">", "must be at most")
+			}
+		}
+		if schema.ExclusiveMaximum != nil && schema.ExclusiveMaximum.IsB() {
+			bound(schema.ExclusiveMaximum.B, ">=", "must be less than")
+		}
+
+	case "array":
+		if schema.MinItems != nil && *schema.MinItems > 0 {
+			check(
+				fmt.Sprintf("len(%s) < %d", expr, *schema.MinItems),
+				fmt.Sprintf("must have at least %s", plural(*schema.MinItems, "item")))
+		}
+		if schema.MaxItems != nil {
+			check(
+				fmt.Sprintf("len(%s) > %d", expr, *schema.MaxItems),
+				fmt.Sprintf("must have at most %s", plural(*schema.MaxItems, "item")))
+		}
+
+		if schema.Items == nil || !schema.Items.IsA() {
+			break
+		}
+
+		index := v.newVar("i")
+		item := v.newVar("v")
+		checks := v.value(indent+1, item,
+			fmt.Sprintf("validationIndex(%s, %s)", pathExpr, index),
+			path.Push("_[]", schema.Items.A), false, depth)
+		if checks != "" {
+			fmt.Fprintf(&b, "%sfor %s, %s := range %s {\n", tabs, index, item, expr)
+			b.WriteString(checks)
+			fmt.Fprintf(&b, "%s}\n", tabs)
+		}
+
+	case "object":
+		// Only inline objects are generated as anonymous structs, since
+		// referenced ones are handled above.
+		if proxyIsGeneratedReference(proxy) {
+			break
+		}
+		b.WriteString(v.properties(indent, expr, pathExpr, path, objectProperties(schema)))
+	}
+
+	return b.String()
+}
+
+// plural returns n followed by the given noun, which is made plural if n isn't
+// 1.
+func plural(n int64, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.FormatInt(n, 10) + " " + noun + "s"
+}